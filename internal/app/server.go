@@ -11,6 +11,7 @@ import (
 
 	"ventichat/internal/handler"
 	"ventichat/internal/repository"
+	"ventichat/internal/token"
 	"ventichat/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +31,11 @@ func StartServer() error {
 	}
 	utils.Info("Redis连接成功")
 
+	// 初始化令牌服务
+	if err := token.Init(); err != nil {
+		return fmt.Errorf("初始化令牌服务失败: %v", err)
+	}
+
 	// 设置Gin模式
 	if utils.AppConfig.Server.Level == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -37,6 +43,12 @@ func StartServer() error {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// 启动WebSocket连接管理器和后台任务
+	go handler.Manager.Start()
+	go handler.StartEmailDigestWorker()
+	go handler.StartGroupMuteWorker()
+	go handler.StartGroupRequestExpiryWorker()
+
 	// 创建Gin引擎
 	router := gin.Default()
 
@@ -51,6 +63,7 @@ func StartServer() error {
 	router.Static("/js", "./web/js")
 	router.Static("/fonts", "./web/fonts")
 	router.Static("/default", "./web/default")
+	router.Static("/uploads", "./uploads")
 	
 	// 为根路径和各种页面提供静态文件服务
 	router.StaticFile("/", "./web/index.html")
@@ -7,13 +7,19 @@ import (
 
 	"ventichat/internal/model"
 	"ventichat/internal/repository"
+	"ventichat/internal/token"
 	"ventichat/internal/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/go-redis/redis/v8"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	loginChallengeTTL         = 5 * time.Minute // 第二步验证的挑战令牌有效期
+	maxLoginChallengeAttempts = 5               // 单个挑战令牌允许的验证失败次数
+)
+
 // LoginUser 用户登录
 func LoginUser(c *gin.Context) {
 	var req struct {
@@ -32,7 +38,14 @@ func LoginUser(c *gin.Context) {
 	// 根据用户名或邮箱查找用户
 	var user model.User
 	result := repository.DB.Where("username = ? OR email = ?", req.Username, req.Username).First(&user)
+
+	// 检查IP是否被封禁、账号是否被锁定
+	if rejectBlockedLogin(c, user.ID, req.Username) {
+		return
+	}
+
 	if result.Error != nil {
+		recordLoginFailure(c, 0, req.Username, "user_not_found")
 		c.JSON(401, gin.H{
 			"error": "用户名或密码错误",
 		})
@@ -62,11 +75,13 @@ func LoginUser(c *gin.Context) {
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		recordLoginFailure(c, user.ID, req.Username, "wrong_password")
 		c.JSON(401, gin.H{
 			"error": "用户名或密码错误",
 		})
 		return
 	}
+	clearLoginFailures(user.ID)
 
 	// 检查邮箱是否已验证（如果服务器要求邮箱验证）
 	if utils.AppConfig.Email.Enable && !user.EmailVerified {
@@ -76,34 +91,61 @@ func LoginUser(c *gin.Context) {
 		return
 	}
 
-	// 生成JWT Token
-	token, err := generateToken(user.ID, user.Username, user.IsAdmin)
+	completeLogin(c, &user)
+}
+
+// completeLogin 第一步验证通过后完成登录
+// 启用双因素认证或注册了通行密钥的用户需要完成第二步验证才能登录
+func completeLogin(c *gin.Context, user *model.User) {
+	if methods := secondFactorMethods(user); len(methods) > 0 {
+		challengeToken, err := createLoginChallenge(user.ID)
+		if err != nil {
+			utils.Errorf("存储登录挑战失败: %v", err)
+			c.JSON(500, gin.H{
+				"error": "登录失败，请稍后重试",
+			})
+			return
+		}
+
+		c.JSON(200, gin.H{
+			"message":             "请完成双因素认证",
+			"two_factor_required": true,
+			"methods":             methods,
+			"challenge_token":     challengeToken,
+			"expires_in":          int(loginChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	issueLoginToken(c, user)
+}
+
+// issueLoginToken 为通过验证的用户创建登录会话，签发访问令牌和刷新令牌并返回登录结果
+func issueLoginToken(c *gin.Context, user *model.User) {
+	session, refreshToken, err := createUserSession(c, user.ID)
 	if err != nil {
+		utils.Errorf("创建登录会话失败: %v", err)
 		c.JSON(500, gin.H{
-			"error": "生成令牌失败",
+			"error": "创建登录会话失败",
 		})
 		return
 	}
 
-	// 将Token存储到Redis，设置过期时间
-	tokenKey := "token:" + token
-	ctx := context.Background()
-	expiration := time.Duration(utils.AppConfig.JWT.Expiration) * time.Hour
-	err = repository.RDB.SetEX(ctx, tokenKey, fmt.Sprintf("%d", user.ID), expiration).Err()
+	accessToken, err := issueAccessToken(user, session.ID)
 	if err != nil {
-		utils.Errorf("存储令牌失败: %v", err)
+		utils.Errorf("签发访问令牌失败: %v", err)
 		c.JSON(500, gin.H{
-			"error": "存储令牌失败",
+			"error": "生成令牌失败",
 		})
 		return
 	}
 
-	// 记录登录记录
-	go recordLogin(user.ID, c.ClientIP(), c.GetHeader("User-Agent"))
-
 	c.JSON(200, gin.H{
-		"message": "登录成功",
-		"token":   token,
+		"message":       "登录成功",
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(accessTokenTTL().Seconds()),
+		"session_id":    session.ID,
 		"user": gin.H{
 			"id":       user.ID,
 			"username": user.Username,
@@ -113,38 +155,124 @@ func LoginUser(c *gin.Context) {
 	})
 }
 
-// generateToken 生成JWT Token
-func generateToken(userID uint64, username string, isAdmin bool) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id":  userID,
-		"username": username,
-		"is_admin": isAdmin,
-		"exp":      time.Now().Add(time.Duration(utils.AppConfig.JWT.Expiration) * time.Hour).Unix(),
-	}
+// isLoginBanned 检查用户是否处于封禁期内
+func isLoginBanned(user *model.User) bool {
+	return user.IsBanned && (user.BannedTexpires == nil || user.BannedTexpires.After(time.Now()))
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// secondFactorMethods 获取用户可用的第二步验证方式
+func secondFactorMethods(user *model.User) []string {
+	var methods []string
+	if user.IsTotpEnabled {
+		methods = append(methods, "totp")
+	}
+	var credentialCount int64
+	repository.DB.Model(&model.WebAuthn{}).Where("user_id = ?", user.ID).Count(&credentialCount)
+	if credentialCount > 0 {
+		methods = append(methods, "webauthn")
+	}
+	return methods
+}
 
-	// 使用配置中的密钥签名
-	signedToken, err := token.SignedString([]byte(utils.AppConfig.JWT.Key))
+// createLoginChallenge 为已通过密码验证的用户生成第二步验证使用的挑战令牌
+func createLoginChallenge(userID uint64) (string, error) {
+	challengeToken, err := utils.GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	err = repository.RDB.SetEX(context.Background(), "login_challenge:"+challengeToken, fmt.Sprintf("%d", userID), loginChallengeTTL).Err()
 	if err != nil {
 		return "", err
 	}
+	return challengeToken, nil
+}
 
-	return signedToken, nil
+// getLoginChallengeUser 根据挑战令牌获取待完成登录的用户，失败时直接返回错误响应
+func getLoginChallengeUser(c *gin.Context, challengeToken string) (*model.User, bool) {
+	ctx := context.Background()
+	challengeKey := "login_challenge:" + challengeToken
+	userIDStr, err := repository.RDB.Get(ctx, challengeKey).Result()
+	if err == redis.Nil {
+		c.JSON(401, gin.H{
+			"error": "登录已过期，请重新登录",
+		})
+		return nil, false
+	}
+	if err != nil {
+		utils.Errorf("获取登录挑战失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "服务器内部错误",
+		})
+		return nil, false
+	}
+
+	var user model.User
+	if err := repository.DB.First(&user, utils.StringToUint64(userIDStr)).Error; err != nil {
+		clearLoginChallenge(challengeToken)
+		c.JSON(401, gin.H{
+			"error": "登录已过期，请重新登录",
+		})
+		return nil, false
+	}
+
+	if isLoginBanned(&user) {
+		clearLoginChallenge(challengeToken)
+		c.JSON(401, gin.H{
+			"error": "账号已被封禁",
+		})
+		return nil, false
+	}
+
+	// 第二步验证同样受IP封禁和账号锁定限制
+	if rejectBlockedLogin(c, user.ID, user.Username) {
+		return nil, false
+	}
+
+	return &user, true
 }
 
-// recordLogin 记录登录信息
-func recordLogin(userID uint64, ip, userAgent string) {
+// failLoginChallenge 记录一次第二步验证失败，失败次数过多时作废挑战令牌，需要重新输入密码
+// 失败同时计入IP和账号的登录失败次数，避免通过重新获取挑战令牌无限尝试
+func failLoginChallenge(c *gin.Context, user *model.User, challengeToken, message string) {
+	recordLoginFailure(c, user.ID, user.Username, "second_factor_failed")
+
+	ctx := context.Background()
+	attemptsKey := "login_challenge:" + challengeToken + ":attempts"
+	attempts, _ := repository.RDB.Incr(ctx, attemptsKey).Result()
+	repository.RDB.Expire(ctx, attemptsKey, loginChallengeTTL)
+	if attempts >= maxLoginChallengeAttempts {
+		clearLoginChallenge(challengeToken)
+		c.JSON(401, gin.H{
+			"error": "验证失败次数过多，请重新登录",
+		})
+		return
+	}
+
+	c.JSON(401, gin.H{
+		"error": message,
+	})
+}
+
+// clearLoginChallenge 删除挑战令牌及其失败次数
+func clearLoginChallenge(challengeToken string) {
+	challengeKey := "login_challenge:" + challengeToken
+	repository.RDB.Del(context.Background(), challengeKey, challengeKey+":attempts")
+}
+
+// recordLogin 记录登录信息，返回登录日志ID
+func recordLogin(userID uint64, ip, userAgent string) uint64 {
 	loginRecord := model.LoginRecord{
 		UserID:    userID,
 		IP:        ip,
-		UserAgent: userAgent,
+		UserAgent: truncateString(userAgent, 255),
+		Status:    loginStatusSuccess,
 	}
 
 	result := repository.DB.Create(&loginRecord)
 	if result.Error != nil {
 		utils.Errorf("记录登录信息失败: %v", result.Error)
 	}
+	return loginRecord.ID
 }
 
 // LogoutUser 用户退出登录
@@ -170,13 +298,24 @@ func LogoutUser(c *gin.Context) {
 	}
 	
 	// 验证JWT令牌
-	_, err := validateToken(tokenString)  // 使用_忽略claims，因为我们只关心令牌是否有效
+	claims, err := token.Parse(tokenString)
 	if err != nil {
 		c.JSON(401, gin.H{
 			"error": "无效的令牌",
 		})
 		return
 	}
+
+	// 注销当前登录会话，使刷新令牌失效
+	if claims.SessionID != 0 {
+		var userID uint64
+		if userIDStr, err := repository.RDB.Get(context.Background(), "token:"+tokenString).Result(); err == nil {
+			userID = utils.StringToUint64(userIDStr)
+		}
+		if userID != 0 {
+			revokeUserSessions(userID, []uint64{claims.SessionID}, "logout")
+		}
+	}
 	
 	// 从Redis中删除该令牌
 	tokenKey := "token:" + tokenString
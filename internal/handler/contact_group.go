@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 每个用户最多创建的好友分组数量
+const maxContactGroups = 50
+
+// loadFriendRemarks 获取用户为好友设置的备注名
+func loadFriendRemarks(userID uint64) map[uint64]string {
+	var friends []model.Friend
+	repository.DB.Select("friend_id, remark").Where("user_id = ? AND remark <> ''", userID).Find(&friends)
+
+	remarks := make(map[uint64]string, len(friends))
+	for _, friend := range friends {
+		remarks[friend.FriendID] = friend.Remark
+	}
+	return remarks
+}
+
+// loadFriendContactGroups 获取好友所在的分组ID
+func loadFriendContactGroups(userID uint64) map[uint64][]uint64 {
+	var members []model.ContactGroupMember
+	repository.DB.Where("user_id = ?", userID).Find(&members)
+
+	result := make(map[uint64][]uint64)
+	for _, member := range members {
+		result[member.FriendID] = append(result[member.FriendID], member.ContactGroupID)
+	}
+	return result
+}
+
+// GetContactGroups 获取好友分组列表
+func GetContactGroups(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var contactGroups []model.ContactGroup
+	err := repository.DB.Where("user_id = ?", currentUserID.(uint64)).Order("sort_order ASC, id ASC").Find(&contactGroups).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取好友分组失败",
+		})
+		return
+	}
+
+	// 统计每个分组的好友数量
+	type groupCount struct {
+		ContactGroupID uint64
+		Count          int64
+	}
+	var counts []groupCount
+	repository.DB.Model(&model.ContactGroupMember{}).
+		Select("contact_group_id, COUNT(*) AS count").
+		Where("user_id = ?", currentUserID.(uint64)).
+		Group("contact_group_id").Scan(&counts)
+	countMap := make(map[uint64]int64, len(counts))
+	for _, count := range counts {
+		countMap[count.ContactGroupID] = count.Count
+	}
+
+	groupData := make([]gin.H, len(contactGroups))
+	for i, group := range contactGroups {
+		groupData[i] = gin.H{
+			"id":           group.ID,
+			"name":         group.Name,
+			"sort_order":   group.SortOrder,
+			"friend_count": countMap[group.ID],
+			"created_at":   group.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contact_groups": groupData,
+	})
+}
+
+// CreateContactGroup 创建好友分组
+func CreateContactGroup(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required,max=50"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var count int64
+	repository.DB.Model(&model.ContactGroup{}).Where("user_id = ?", currentUserID.(uint64)).Count(&count)
+	if count >= maxContactGroups {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "好友分组数量已达上限",
+		})
+		return
+	}
+
+	var existing model.ContactGroup
+	if repository.DB.Where("user_id = ? AND name = ?", currentUserID.(uint64), req.Name).First(&existing).Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "分组名称已存在",
+		})
+		return
+	}
+
+	// 新分组排在最后
+	var maxOrder int
+	repository.DB.Model(&model.ContactGroup{}).Where("user_id = ?", currentUserID.(uint64)).
+		Select("COALESCE(MAX(sort_order), 0)").Scan(&maxOrder)
+
+	contactGroup := model.ContactGroup{
+		UserID:    currentUserID.(uint64),
+		Name:      req.Name,
+		SortOrder: maxOrder + 1,
+	}
+
+	if err := repository.DB.Create(&contactGroup).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "创建好友分组失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "好友分组创建成功",
+		"contact_group": contactGroup,
+	})
+}
+
+// UpdateContactGroup 修改好友分组名称
+func UpdateContactGroup(c *gin.Context) {
+	contactGroupID, err := strconv.ParseUint(c.Param("contact_group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的分组ID",
+		})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required,max=50"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var contactGroup model.ContactGroup
+	if err := repository.DB.Where("id = ? AND user_id = ?", contactGroupID, currentUserID.(uint64)).First(&contactGroup).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "好友分组不存在",
+		})
+		return
+	}
+
+	var existing model.ContactGroup
+	if repository.DB.Where("user_id = ? AND name = ? AND id <> ?", currentUserID.(uint64), req.Name, contactGroupID).First(&existing).Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "分组名称已存在",
+		})
+		return
+	}
+
+	contactGroup.Name = req.Name
+	if err := repository.DB.Save(&contactGroup).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "修改好友分组失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "好友分组修改成功",
+		"contact_group": contactGroup,
+	})
+}
+
+// SortContactGroups 调整好友分组顺序
+func SortContactGroups(c *gin.Context) {
+	var req struct {
+		ContactGroupIDs []uint64 `json:"contact_group_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		for i, contactGroupID := range req.ContactGroupIDs {
+			if err := tx.Model(&model.ContactGroup{}).
+				Where("id = ? AND user_id = ?", contactGroupID, currentUserID.(uint64)).
+				Update("sort_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "调整分组顺序失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "分组顺序已更新",
+	})
+}
+
+// DeleteContactGroup 删除好友分组（不会删除组内好友）
+func DeleteContactGroup(c *gin.Context) {
+	contactGroupID, err := strconv.ParseUint(c.Param("contact_group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的分组ID",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var contactGroup model.ContactGroup
+	if err := repository.DB.Where("id = ? AND user_id = ?", contactGroupID, currentUserID.(uint64)).First(&contactGroup).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "好友分组不存在",
+		})
+		return
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("contact_group_id = ?", contactGroup.ID).Delete(&model.ContactGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&contactGroup).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "删除好友分组失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "好友分组删除成功",
+	})
+}
+
+// SetFriendContactGroups 设置好友所属的分组
+func SetFriendContactGroups(c *gin.Context) {
+	friendID, err := strconv.ParseUint(c.Param("friend_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的好友ID",
+		})
+		return
+	}
+
+	var req struct {
+		ContactGroupIDs []uint64 `json:"contact_group_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var friend model.Friend
+	if err := repository.DB.Where("user_id = ? AND friend_id = ?", currentUserID.(uint64), friendID).First(&friend).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "好友关系不存在",
+		})
+		return
+	}
+
+	// 去除重复的分组ID
+	seen := make(map[uint64]bool, len(req.ContactGroupIDs))
+	contactGroupIDs := make([]uint64, 0, len(req.ContactGroupIDs))
+	for _, id := range req.ContactGroupIDs {
+		if !seen[id] {
+			seen[id] = true
+			contactGroupIDs = append(contactGroupIDs, id)
+		}
+	}
+	req.ContactGroupIDs = contactGroupIDs
+
+	// 只允许使用自己的分组
+	if len(req.ContactGroupIDs) > 0 {
+		var count int64
+		repository.DB.Model(&model.ContactGroup{}).
+			Where("id IN ? AND user_id = ?", req.ContactGroupIDs, currentUserID.(uint64)).Count(&count)
+		if int(count) != len(req.ContactGroupIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "好友分组不存在",
+			})
+			return
+		}
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND friend_id = ?", currentUserID.(uint64), friendID).Delete(&model.ContactGroupMember{}).Error; err != nil {
+			return err
+		}
+		for _, contactGroupID := range req.ContactGroupIDs {
+			member := model.ContactGroupMember{
+				ContactGroupID: contactGroupID,
+				UserID:         currentUserID.(uint64),
+				FriendID:       friendID,
+			}
+			if err := tx.Create(&member).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "设置好友分组失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "好友分组设置成功",
+		"contact_group_ids": req.ContactGroupIDs,
+	})
+}
@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConversationItem 会话列表项
+type ConversationItem struct {
+	Type         string        `json:"type"` // 'user' 或 'group'
+	ID           uint64        `json:"id"`
+	Name         string        `json:"name"`
+	AvatarURL    string        `json:"avatar_url"`
+	Unread       int           `json:"unread"`
+	LastMessage  *ChatMessage  `json:"last_message,omitempty"`
+	Draft        *DraftPayload `json:"draft,omitempty"`
+	LastActiveAt time.Time     `json:"last_active_at"`
+
+	Setting *model.ConversationSetting `json:"setting,omitempty"`
+}
+
+// loadLastMessage 获取会话的最后一条消息
+// 群聊只在成员可以访问的频道中查找，避免泄露受限频道的消息
+func loadLastMessage(userID uint64, conversationType string, conversationID uint64, member *model.GroupMember) *ChatMessage {
+	var msg model.Message
+	query := repository.DB.Order("sent_at DESC, id DESC")
+	if conversationType == "group" {
+		query = query.Where("receiver_type = 'group' AND receiver_id = ? AND channel_id IN ?", conversationID, accessibleGroupChannelIDs(member))
+	} else {
+		query = query.Where("receiver_type = 'user' AND ((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?))",
+			userID, conversationID, conversationID, userID)
+	}
+	if err := query.First(&msg).Error; err != nil {
+		return nil
+	}
+
+	return &ChatMessage{
+		MessageID:    msg.ID,
+		SenderID:     msg.SenderID,
+		ReceiverType: msg.ReceiverType,
+		ReceiverID:   msg.ReceiverID,
+		Content:      msg.Content,
+		MessageType:  msg.MessageType,
+		FileName:     msg.FileName,
+		StickerID:    msg.StickerID,
+		ChannelID:    msg.ChannelID,
+		SentAt:       msg.SentAt,
+	}
+}
+
+// GetConversations 获取会话概览（好友和群聊）
+// 置顶会话排在最前，其次按自定义排序值从大到小，最后按最后活跃时间排序
+// 默认不返回已归档和已隐藏的会话，archived=true 时只返回已归档的会话
+func GetConversations(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+	userID := currentUserID.(uint64)
+
+	var friends []model.Friend
+	if err := repository.DB.Preload("Friend").Where("user_id = ? AND status = 'active'", userID).Find(&friends).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取好友列表失败",
+		})
+		return
+	}
+
+	var groupMembers []model.GroupMember
+	if err := repository.DB.Where("user_id = ?", userID).Find(&groupMembers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取群聊列表失败",
+		})
+		return
+	}
+
+	groupIDs := make([]uint64, 0, len(groupMembers))
+	for _, member := range groupMembers {
+		groupIDs = append(groupIDs, member.GroupID)
+	}
+	groups := make(map[uint64]model.Group)
+	if len(groupIDs) > 0 {
+		var groupList []model.Group
+		repository.DB.Where("id IN ?", groupIDs).Find(&groupList)
+		for _, group := range groupList {
+			groups[group.ID] = group
+		}
+	}
+
+	showArchived := c.Query("archived") == "true"
+	drafts := loadDrafts(userID)
+	settings := loadConversationSettings(userID)
+	conversations := make([]ConversationItem, 0, len(friends)+len(groupMembers))
+
+	for _, friend := range friends {
+		name := friend.Remark
+		if name == "" {
+			name = friend.Friend.Nickname
+		}
+		if name == "" {
+			name = friend.Friend.Username
+		}
+		conversations = append(conversations, ConversationItem{
+			Type:      "user",
+			ID:        friend.FriendID,
+			Name:      name,
+			AvatarURL: friend.Friend.AvatarURL,
+			Unread:    friend.Unread,
+		})
+	}
+
+	for _, member := range groupMembers {
+		group, ok := groups[member.GroupID]
+		if !ok {
+			continue
+		}
+		conversations = append(conversations, ConversationItem{
+			Type:      "group",
+			ID:        group.ID,
+			Name:      group.Name,
+			AvatarURL: group.AvatarURL,
+			Unread:    member.Unread,
+		})
+	}
+
+	// 按归档和隐藏状态过滤
+	visible := conversations[:0]
+	for _, item := range conversations {
+		item.Setting = settings[conversationKey(item.Type, item.ID)]
+		archived := item.Setting != nil && item.Setting.IsArchived
+		hidden := item.Setting != nil && item.Setting.IsHidden
+		if archived != showArchived || (hidden && !showArchived) {
+			continue
+		}
+		visible = append(visible, item)
+	}
+	conversations = visible
+
+	members := make(map[uint64]*model.GroupMember, len(groupMembers))
+	for i := range groupMembers {
+		members[groupMembers[i].GroupID] = &groupMembers[i]
+	}
+
+	for i := range conversations {
+		item := &conversations[i]
+		item.LastMessage = loadLastMessage(userID, item.Type, item.ID, members[item.ID])
+		if item.LastMessage != nil {
+			item.LastActiveAt = item.LastMessage.SentAt
+		}
+		if draft, ok := drafts[conversationKey(item.Type, item.ID)]; ok {
+			item.Draft = &draft
+			if draftTime := time.UnixMilli(draft.UpdatedAt); draftTime.After(item.LastActiveAt) {
+				item.LastActiveAt = draftTime
+			}
+		}
+	}
+
+	sort.SliceStable(conversations, func(i, j int) bool {
+		a, b := conversations[i].Setting, conversations[j].Setting
+		aPinned, bPinned := a != nil && a.IsPinned, b != nil && b.IsPinned
+		if aPinned != bPinned {
+			return aPinned
+		}
+		aOrder, bOrder := 0, 0
+		if a != nil {
+			aOrder = a.SortOrder
+		}
+		if b != nil {
+			bOrder = b.SortOrder
+		}
+		if aOrder != bOrder {
+			return aOrder > bOrder
+		}
+		return conversations[i].LastActiveAt.After(conversations[j].LastActiveAt)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversations": conversations,
+	})
+}
+
+// isConversationMuted 判断会话当前是否处于免打扰状态
+func isConversationMuted(setting *model.ConversationSetting) bool {
+	if setting == nil || !setting.IsMuted {
+		return false
+	}
+	return setting.MutedUntil == nil || setting.MutedUntil.After(time.Now())
+}
+
+// loadConversationSettings 获取用户的会话设置，按会话索引
+func loadConversationSettings(userID uint64) map[string]*model.ConversationSetting {
+	var settings []model.ConversationSetting
+	repository.DB.Where("user_id = ?", userID).Find(&settings)
+
+	result := make(map[string]*model.ConversationSetting, len(settings))
+	for i := range settings {
+		result[conversationKey(settings[i].ConversationType, settings[i].ConversationID)] = &settings[i]
+	}
+	return result
+}
+
+// mutedUserIDs 从候选用户中筛选出对该会话开启免打扰的用户
+// 私聊会话从接收者视角看，会话ID为发送者ID
+func mutedUserIDs(userIDs []uint64, conversationType string, conversationIDFor func(userID uint64) uint64) map[uint64]bool {
+	muted := make(map[uint64]bool)
+	if len(userIDs) == 0 {
+		return muted
+	}
+
+	var settings []model.ConversationSetting
+	repository.DB.Where("user_id IN ? AND conversation_type = ? AND is_muted = ?", userIDs, conversationType, true).Find(&settings)
+	for i := range settings {
+		if settings[i].ConversationID == conversationIDFor(settings[i].UserID) && isConversationMuted(&settings[i]) {
+			muted[settings[i].UserID] = true
+		}
+	}
+	return muted
+}
+
+// unhideConversation 收到新消息时，恢复被隐藏的会话
+func unhideConversation(chatMsg ChatMessage) {
+	query := repository.DB.Model(&model.ConversationSetting{}).Where("is_hidden = ?", true)
+	if chatMsg.ReceiverType == "group" {
+		query = query.Where("conversation_type = 'group' AND conversation_id = ?", chatMsg.ReceiverID)
+	} else {
+		query = query.Where("conversation_type = 'user' AND ((user_id = ? AND conversation_id = ?) OR (user_id = ? AND conversation_id = ?))",
+			chatMsg.SenderID, chatMsg.ReceiverID, chatMsg.ReceiverID, chatMsg.SenderID)
+	}
+	query.Update("is_hidden", false)
+}
+
+// GetConversationSettings 获取会话设置列表
+func GetConversationSettings(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var settings []model.ConversationSetting
+	if err := repository.DB.Where("user_id = ?", currentUserID.(uint64)).Find(&settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取会话设置失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+	})
+}
+
+// UpdateConversationSetting 更新会话设置（免打扰、置顶、归档、隐藏、排序）
+func UpdateConversationSetting(c *gin.Context) {
+	conversationType := c.Param("type")
+	conversationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || (conversationType != "user" && conversationType != "group") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的会话",
+		})
+		return
+	}
+
+	var req struct {
+		IsMuted    *bool      `json:"is_muted"`
+		MutedUntil *time.Time `json:"muted_until"` // 为空表示永久免打扰
+		IsPinned   *bool      `json:"is_pinned"`
+		IsArchived *bool      `json:"is_archived"`
+		IsHidden   *bool      `json:"is_hidden"`
+		SortOrder  *int       `json:"sort_order"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	if !canAccessConversation(currentUserID.(uint64), conversationType, conversationID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "无权访问该会话",
+		})
+		return
+	}
+
+	if req.IsMuted != nil && *req.IsMuted && req.MutedUntil != nil && !req.MutedUntil.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "免打扰结束时间必须晚于当前时间",
+		})
+		return
+	}
+
+	setting := model.ConversationSetting{
+		UserID:           currentUserID.(uint64),
+		ConversationType: conversationType,
+		ConversationID:   conversationID,
+	}
+	result := repository.DB.Where("user_id = ? AND conversation_type = ? AND conversation_id = ?",
+		currentUserID.(uint64), conversationType, conversationID).FirstOrInit(&setting)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "查询会话设置失败",
+		})
+		return
+	}
+
+	if req.IsMuted != nil {
+		setting.IsMuted = *req.IsMuted
+		setting.MutedUntil = nil
+		if *req.IsMuted {
+			setting.MutedUntil = req.MutedUntil
+		}
+	}
+	if req.IsPinned != nil {
+		setting.IsPinned = *req.IsPinned
+		setting.PinnedAt = nil
+		if *req.IsPinned {
+			now := time.Now()
+			setting.PinnedAt = &now
+		}
+	}
+	if req.IsArchived != nil {
+		setting.IsArchived = *req.IsArchived
+	}
+	if req.IsHidden != nil {
+		setting.IsHidden = *req.IsHidden
+	}
+	if req.SortOrder != nil {
+		setting.SortOrder = *req.SortOrder
+	}
+	setting.UpdatedAt = time.Now()
+
+	if err := repository.DB.Save(&setting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "更新会话设置失败",
+		})
+		return
+	}
+
+	// 同步到该用户的所有设备
+	Manager.SendToUsers(WebSocketMessage{
+		Type:      "conversation_settings_updated",
+		Payload:   setting,
+		Timestamp: time.Now(),
+	}, currentUserID.(uint64))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "会话设置已更新",
+		"setting": setting,
+	})
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 草稿最大长度（字符）
+const maxDraftLength = 10000
+
+// 客户端时间最多允许超前服务器的时间，防止错误的时钟永久占据草稿
+const maxDraftClockSkew = time.Minute
+
+// DraftPayload 草稿同步数据
+type DraftPayload struct {
+	ConversationType string `json:"conversation_type"`
+	ConversationID   uint64 `json:"conversation_id"`
+	Content          string `json:"content"`
+	UpdatedAt        int64  `json:"updated_at"` // 客户端修改时间（毫秒时间戳）
+}
+
+// newDraftPayload 将草稿记录转换为同步数据
+func newDraftPayload(draft *model.Draft) DraftPayload {
+	return DraftPayload{
+		ConversationType: draft.ConversationType,
+		ConversationID:   draft.ConversationID,
+		Content:          draft.Content,
+		UpdatedAt:        draft.UpdatedAt.UnixMilli(),
+	}
+}
+
+// canAccessConversation 检查用户能否访问会话
+func canAccessConversation(userID uint64, conversationType string, conversationID uint64) bool {
+	switch conversationType {
+	case "user":
+		var friend model.Friend
+		return repository.DB.Where("user_id = ? AND friend_id = ?", userID, conversationID).First(&friend).Error == nil
+	case "group":
+		var groupMember model.GroupMember
+		return repository.DB.Where("group_id = ? AND user_id = ?", conversationID, userID).First(&groupMember).Error == nil
+	}
+	return false
+}
+
+// saveDraft 按最后写入者胜出的规则保存草稿
+// 返回当前生效的草稿以及本次写入是否被采纳
+func saveDraft(userID uint64, payload DraftPayload) (*model.Draft, bool, error) {
+	updatedAt := time.UnixMilli(payload.UpdatedAt)
+	if payload.UpdatedAt <= 0 || updatedAt.After(time.Now().Add(maxDraftClockSkew)) {
+		updatedAt = time.Now()
+	}
+
+	var draft model.Draft
+	applied := false
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND conversation_type = ? AND conversation_id = ?",
+				userID, payload.ConversationType, payload.ConversationID).
+			First(&draft).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err == nil && !updatedAt.After(draft.UpdatedAt) {
+			// 已有更新的草稿，忽略本次写入
+			return nil
+		}
+
+		applied = true
+		draft.UserID = userID
+		draft.ConversationType = payload.ConversationType
+		draft.ConversationID = payload.ConversationID
+		draft.Content = payload.Content
+		draft.UpdatedAt = updatedAt
+		return tx.Save(&draft).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &draft, applied, nil
+}
+
+// clearDraft 发送消息后清空会话草稿，并通知用户的所有连接
+func clearDraft(manager *ClientManager, userID uint64, conversationType string, conversationID uint64) {
+	result := repository.DB.Model(&model.Draft{}).
+		Where("user_id = ? AND conversation_type = ? AND conversation_id = ? AND content <> ''",
+			userID, conversationType, conversationID).
+		Updates(map[string]interface{}{"content": "", "updated_at": time.Now()})
+	if result.Error != nil {
+		utils.Errorf("清空草稿失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	manager.SendToUsers(WebSocketMessage{
+		Type: "draft_updated",
+		Payload: DraftPayload{
+			ConversationType: conversationType,
+			ConversationID:   conversationID,
+			UpdatedAt:        time.Now().UnixMilli(),
+		},
+		Timestamp: time.Now(),
+	}, userID)
+}
+
+// loadDrafts 获取用户的所有非空草稿，按会话索引
+func loadDrafts(userID uint64) map[string]DraftPayload {
+	var drafts []model.Draft
+	repository.DB.Where("user_id = ? AND content <> ''", userID).Find(&drafts)
+
+	result := make(map[string]DraftPayload, len(drafts))
+	for i := range drafts {
+		result[conversationKey(drafts[i].ConversationType, drafts[i].ConversationID)] = newDraftPayload(&drafts[i])
+	}
+	return result
+}
+
+// conversationKey 生成会话索引键
+func conversationKey(conversationType string, conversationID uint64) string {
+	return conversationType + ":" + strconv.FormatUint(conversationID, 10)
+}
+
+// handleDraftMessage 处理WebSocket草稿同步消息
+func (client *Client) handleDraftMessage(wsMessage WebSocketMessage) {
+	payloadBytes, _ := json.Marshal(wsMessage.Payload)
+	var payload DraftPayload
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return
+	}
+	if len([]rune(payload.Content)) > maxDraftLength {
+		return
+	}
+	if !canAccessConversation(client.userID, payload.ConversationType, payload.ConversationID) {
+		return
+	}
+
+	draft, applied, err := saveDraft(client.userID, payload)
+	if err != nil {
+		utils.Errorf("保存草稿失败: %v", err)
+		return
+	}
+
+	syncMsg := WebSocketMessage{
+		Type:      "draft_updated",
+		Payload:   newDraftPayload(draft),
+		Timestamp: time.Now(),
+	}
+
+	if applied {
+		// 同步到该用户的其他设备
+		client.manager.SendToUserExcept(syncMsg, client.userID, client)
+	} else {
+		// 写入被更新的草稿覆盖，将最新草稿回传给当前设备
+		client.manager.SendToClient(syncMsg, client)
+	}
+}
+
+// GetDrafts 获取草稿列表
+func GetDrafts(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var drafts []model.Draft
+	err := repository.DB.Where("user_id = ? AND content <> ''", currentUserID.(uint64)).Order("updated_at DESC").Find(&drafts).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取草稿列表失败",
+		})
+		return
+	}
+
+	draftList := make([]DraftPayload, len(drafts))
+	for i := range drafts {
+		draftList[i] = newDraftPayload(&drafts[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"drafts": draftList,
+	})
+}
+
+// SaveDraft 保存会话草稿
+func SaveDraft(c *gin.Context) {
+	conversationType := c.Param("type")
+	conversationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || (conversationType != "user" && conversationType != "group") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的会话",
+		})
+		return
+	}
+
+	var req struct {
+		Content   string `json:"content"`
+		UpdatedAt int64  `json:"updated_at"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	if len([]rune(req.Content)) > maxDraftLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "草稿内容过长",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	if !canAccessConversation(currentUserID.(uint64), conversationType, conversationID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "无权访问该会话",
+		})
+		return
+	}
+
+	draft, applied, err := saveDraft(currentUserID.(uint64), DraftPayload{
+		ConversationType: conversationType,
+		ConversationID:   conversationID,
+		Content:          req.Content,
+		UpdatedAt:        req.UpdatedAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "保存草稿失败",
+		})
+		return
+	}
+
+	if applied {
+		Manager.SendToUsers(WebSocketMessage{
+			Type:      "draft_updated",
+			Payload:   newDraftPayload(draft),
+			Timestamp: time.Now(),
+		}, currentUserID.(uint64))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"applied": applied,
+		"draft":   newDraftPayload(draft),
+	})
+}
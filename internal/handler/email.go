@@ -2,7 +2,11 @@ package handler
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"ventichat/internal/model"
@@ -10,6 +14,7 @@ import (
 	"ventichat/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -33,7 +38,14 @@ func ResendVerificationEmail(c *gin.Context) {
 	var user model.User
 	result := repository.DB.Where("username = ? OR email = ? OR mobile = ?", 
 		req.Identifier, req.Identifier, req.Identifier).First(&user)
+
+	// 检查IP是否被封禁、账号是否被锁定
+	if rejectBlockedLogin(c, user.ID, req.Identifier) {
+		return
+	}
+
 	if result.Error != nil {
+		recordLoginFailure(c, 0, req.Identifier, "user_not_found")
 		c.JSON(401, gin.H{
 			"error": "用户名、邮箱或手机号不存在",
 		})
@@ -42,11 +54,13 @@ func ResendVerificationEmail(c *gin.Context) {
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		recordLoginFailure(c, user.ID, req.Identifier, "wrong_password")
 		c.JSON(401, gin.H{
 			"error": "密码错误",
 		})
 		return
 	}
+	clearLoginFailures(user.ID)
 
 	// 检查用户是否已被封禁
 	if user.IsBanned {
@@ -190,4 +204,192 @@ func VerifyEmail(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"message": "邮箱验证成功",
 	})
-}
\ No newline at end of file
+}
+const (
+	emailLoginCodeTTL     = 10 * time.Minute // 登录验证码有效期
+	emailLoginCooldown    = time.Minute      // 重新发送验证码的间隔
+	maxEmailLoginAttempts = 5                // 单个验证码允许的验证失败次数
+	emailLoginCodeDigits  = 6                // 登录验证码位数
+)
+
+// generateNumericCode 生成指定位数的随机数字验证码
+func generateNumericCode(digits int) (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < digits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// SendEmailLoginCode 发送邮箱登录验证码
+// 为避免泄露邮箱是否注册，邮箱不存在或不可用于登录时同样返回发送成功
+func SendEmailLoginCode(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	if !utils.AppConfig.Email.Enable {
+		c.JSON(400, gin.H{
+			"error": "服务器未开启邮件服务",
+		})
+		return
+	}
+
+	ctx := context.Background()
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// 限制发送频率
+	cooldownKey := "email_login_cooldown:" + email
+	ok, err := repository.RDB.SetNX(ctx, cooldownKey, 1, emailLoginCooldown).Result()
+	if err != nil {
+		utils.Errorf("设置验证码发送间隔失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "服务器内部错误",
+		})
+		return
+	}
+	if !ok {
+		ttl, _ := repository.RDB.TTL(ctx, cooldownKey).Result()
+		c.JSON(429, gin.H{
+			"error":       "验证码发送过于频繁，请稍后再试",
+			"retry_after": int(ttl.Seconds()),
+		})
+		return
+	}
+
+	response := gin.H{
+		"message":    "如果该邮箱已注册，验证码已发送，请检查邮箱",
+		"expires_in": int(emailLoginCodeTTL.Seconds()),
+	}
+
+	var user model.User
+	if repository.DB.Where("email = ?", email).First(&user).Error != nil ||
+		!user.EmailVerified || isLoginBanned(&user) {
+		c.JSON(200, response)
+		return
+	}
+
+	code, err := generateNumericCode(emailLoginCodeDigits)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "生成验证码失败",
+		})
+		return
+	}
+
+	// 新验证码覆盖旧验证码并重置失败次数
+	codeKey := fmt.Sprintf("email_login:%d", user.ID)
+	_, err = repository.RDB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, codeKey)
+		pipe.HSet(ctx, codeKey, "code", code, "attempts", 0)
+		pipe.Expire(ctx, codeKey, emailLoginCodeTTL)
+		return nil
+	})
+	if err != nil {
+		utils.Errorf("存储登录验证码失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "验证码存储失败",
+		})
+		return
+	}
+
+	go utils.SendLoginCodeEmail(user.Email, code, user.Username, int(emailLoginCodeTTL.Minutes()))
+
+	c.JSON(200, response)
+}
+
+// LoginWithEmailCode 使用邮箱验证码登录
+func LoginWithEmailCode(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Code  string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	var user model.User
+	result := repository.DB.Where("email = ?", email).First(&user)
+
+	// 检查IP是否被封禁、账号是否被锁定
+	if rejectBlockedLogin(c, user.ID, email) {
+		return
+	}
+
+	if result.Error != nil {
+		recordLoginFailure(c, 0, email, "user_not_found")
+		c.JSON(401, gin.H{
+			"error": "验证码错误或已过期",
+		})
+		return
+	}
+
+	ctx := context.Background()
+	codeKey := fmt.Sprintf("email_login:%d", user.ID)
+	stored, err := repository.RDB.HGet(ctx, codeKey, "code").Result()
+	if err != nil {
+		recordLoginFailure(c, user.ID, email, "wrong_code")
+		c.JSON(401, gin.H{
+			"error": "验证码错误或已过期",
+		})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(strings.TrimSpace(req.Code))) != 1 {
+		recordLoginFailure(c, user.ID, email, "wrong_code")
+		// 失败次数过多时作废验证码
+		attempts, _ := repository.RDB.HIncrBy(ctx, codeKey, "attempts", 1).Result()
+		if attempts >= maxEmailLoginAttempts {
+			repository.RDB.Del(ctx, codeKey)
+			c.JSON(401, gin.H{
+				"error": "验证码错误次数过多，请重新获取",
+			})
+			return
+		}
+		c.JSON(401, gin.H{
+			"error": "验证码错误或已过期",
+		})
+		return
+	}
+
+	// 验证码只能使用一次，删除失败说明已被并发请求使用
+	if deleted, err := repository.RDB.Del(ctx, codeKey).Result(); err != nil || deleted != 1 {
+		c.JSON(401, gin.H{
+			"error": "验证码错误或已过期",
+		})
+		return
+	}
+	clearLoginFailures(user.ID)
+
+	if isLoginBanned(&user) {
+		c.JSON(401, gin.H{
+			"error": "账号已被封禁",
+		})
+		return
+	}
+
+	if !user.EmailVerified {
+		c.JSON(401, gin.H{
+			"error": "邮箱未验证，请先验证邮箱",
+		})
+		return
+	}
+
+	completeLogin(c, &user)
+}
@@ -258,9 +258,22 @@ func GetFriends(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户的好友列表
+	// 获取当前用户的好友列表，可按好友分组筛选
 	var friends []model.Friend
-	err := repository.DB.Preload("Friend").Where("user_id = ? AND status = 'active'", currentUserID.(uint64)).Find(&friends).Error
+	query := repository.DB.Preload("Friend").Where("user_id = ? AND status = 'active'", currentUserID.(uint64))
+	if contactGroupIDStr := c.Query("contact_group_id"); contactGroupIDStr != "" {
+		contactGroupID, err := strconv.ParseUint(contactGroupIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的分组ID",
+			})
+			return
+		}
+		groupFriendIDs := repository.DB.Model(&model.ContactGroupMember{}).Select("friend_id").
+			Where("contact_group_id = ? AND user_id = ?", contactGroupID, currentUserID.(uint64))
+		query = query.Where("friend_id IN (?)", groupFriendIDs)
+	}
+	err := query.Find(&friends).Error
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "获取好友列表失败",
@@ -268,15 +281,24 @@ func GetFriends(c *gin.Context) {
 		return
 	}
 
+	contactGroups := loadFriendContactGroups(currentUserID.(uint64))
+
 	// 获取好友的详细信息
 	friendDetails := make([]gin.H, len(friends))
 	for i, friend := range friends {
+		contactGroupIDs := contactGroups[friend.FriendID]
+		if contactGroupIDs == nil {
+			contactGroupIDs = []uint64{}
+		}
 		friendDetails[i] = gin.H{
-			"id":         friend.Friend.ID,
-			"username":   friend.Friend.Username,
-			"nickname":   friend.Friend.Nickname,
-			"avatar_url": friend.Friend.AvatarURL,
-			"unread":     friend.Unread,
+			"id":                friend.Friend.ID,
+			"username":          friend.Friend.Username,
+			"nickname":          friend.Friend.Nickname,
+			"avatar_url":        friend.Friend.AvatarURL,
+			"unread":            friend.Unread,
+			"remark":            friend.Remark,
+			"note":              friend.Note,
+			"contact_group_ids": contactGroupIDs,
 		}
 	}
 
@@ -285,6 +307,77 @@ func GetFriends(c *gin.Context) {
 	})
 }
 
+// UpdateFriendRemark 设置好友备注名和备注信息（仅自己可见）
+func UpdateFriendRemark(c *gin.Context) {
+	friendIDStr := c.Param("friend_id")
+	friendID, err := strconv.ParseUint(friendIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的好友ID",
+		})
+		return
+	}
+
+	var req struct {
+		Remark *string `json:"remark" binding:"omitempty,max=100"`
+		Note   *string `json:"note" binding:"omitempty,max=1000"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var friend model.Friend
+	err = repository.DB.Where("user_id = ? AND friend_id = ?", currentUserID.(uint64), friendID).First(&friend).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "好友关系不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "查询好友关系失败",
+		})
+		return
+	}
+
+	if req.Remark != nil {
+		friend.Remark = *req.Remark
+	}
+	if req.Note != nil {
+		friend.Note = *req.Note
+	}
+
+	err = repository.DB.Model(&friend).Updates(map[string]interface{}{
+		"remark": friend.Remark,
+		"note":   friend.Note,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "设置好友备注失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "好友备注设置成功",
+		"remark":  friend.Remark,
+		"note":    friend.Note,
+	})
+}
+
 // RemoveFriend 删除好友
 func RemoveFriend(c *gin.Context) {
 	friendIDStr := c.Param("friend_id")
@@ -328,6 +421,11 @@ func RemoveFriend(c *gin.Context) {
 		if err := tx.Where("user_id = ? AND friend_id = ?", friendID, currentUserID.(uint64)).Delete(&model.Friend{}).Error; err != nil {
 			return err
 		}
+		// 同时移出双方的好友分组
+		if err := tx.Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)",
+			currentUserID.(uint64), friendID, friendID, currentUserID.(uint64)).Delete(&model.ContactGroupMember{}).Error; err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -1,14 +1,19 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	"ventichat/internal/model"
 	"ventichat/internal/repository"
+	"ventichat/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateGroup 创建群聊
@@ -20,6 +25,8 @@ func CreateGroup(c *gin.Context) {
 		NeedApproval bool   `json:"need_approval"`
 		IsPrivate    bool   `json:"is_private"`
 		Announcement string `json:"announcement"`
+
+		InvitePermission string `json:"invite_permission" binding:"omitempty,oneof=anyone admins"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -52,6 +59,11 @@ func CreateGroup(c *gin.Context) {
 		NeedApproval: req.NeedApproval,
 		IsPrivate:    req.IsPrivate,
 		Announcement: req.Announcement,
+
+		InvitePermission: req.InvitePermission,
+	}
+	if group.InvitePermission == "" {
+		group.InvitePermission = "anyone"
 	}
 
 	result := repository.DB.Create(&group)
@@ -80,6 +92,13 @@ func CreateGroup(c *gin.Context) {
 		return
 	}
 
+	// 创建时填写的公告作为第一条公告记录
+	if req.Announcement != "" {
+		if _, err := createGroupAnnouncement(repository.DB, group.ID, group.OwnerID, req.Announcement, false); err != nil {
+			utils.Errorf("创建群公告记录失败: %v", err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "群聊创建成功",
 		"group":   group,
@@ -121,9 +140,22 @@ func GetGroups(c *gin.Context) {
 		}
 	}
 
-	// 添加群成员数量信息
+	members := make(map[uint64]*model.GroupMember, len(groupMembers))
+	for i := range groupMembers {
+		members[groupMembers[i].GroupID] = &groupMembers[i]
+	}
+
+	// 添加群成员数量和当前用户的权限信息
 	var groupData []gin.H
 	for _, group := range groups {
+		granted := memberPermissions(&group, members[group.ID])
+		permissions := []string{}
+		for _, p := range groupPermissions {
+			if granted[p] {
+				permissions = append(permissions, p)
+			}
+		}
+
 		var memberCount int64
 		repository.DB.Model(&model.GroupMember{}).Where("group_id = ?", group.ID).Count(&memberCount)
 
@@ -136,8 +168,12 @@ func GetGroups(c *gin.Context) {
 			"announcement":  group.Announcement,
 			"need_approval": group.NeedApproval,
 			"is_private":    group.IsPrivate,
-			"is_mute":       group.IsMute,
+			"invite_permission": group.InvitePermission,
+			"is_mute":       isMuteActive(group.IsMute, group.MutePermanent, group.MuteTexpires),
+			"mute_texpires": group.MuteTexpires,
 			"member_count":  memberCount,
+			"max_members":   groupMemberLimit(&group),
+			"permissions":   permissions,
 			"created_at":    group.CreatedAt,
 		})
 	}
@@ -202,7 +238,9 @@ func GetGroupMembers(c *gin.Context) {
 			"nickname":     user.Nickname,
 			"avatar_url":   user.AvatarURL,
 			"role":         member.Role,
-			"is_mute":      member.IsMute,
+			"role_id":      member.RoleID,
+			"is_mute":      isMuteActive(member.IsMute, member.MutePermanent, member.MuteTexpires),
+			"mute_texpires": member.MuteTexpires,
 			"joined_at":    member.JoinedAt,
 		})
 	}
@@ -212,7 +250,7 @@ func GetGroupMembers(c *gin.Context) {
 	})
 }
 
-// JoinGroup 申请加入群聊
+// JoinGroup 申请加入群聊，可携带邀请码通过邀请链接加入
 func JoinGroup(c *gin.Context) {
 	groupIDStr := c.Param("group_id")
 	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
@@ -223,6 +261,23 @@ func JoinGroup(c *gin.Context) {
 		return
 	}
 
+	// 请求体可选
+	var req struct {
+		InviteCode string `json:"invite_code"`
+		Message    string `json:"message" binding:"max=500"`
+		Answers    []struct {
+			QuestionID uint64 `json:"question_id"`
+			Answer     string `json:"answer" binding:"max=500"`
+		} `json:"answers" binding:"dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
 	// 获取当前用户ID
 	currentUserID, exists := c.Get("user_id")
 	if !exists {
@@ -252,12 +307,68 @@ func JoinGroup(c *gin.Context) {
 		return
 	}
 
+	// 检查用户是否在群聊黑名单中
+	if findGroupBan(groupID, currentUserID.(uint64)) != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您已被禁止加入该群聊",
+		})
+		return
+	}
+
+	// 群聊已满时不再接受加入和申请
+	if err := checkGroupCapacity(repository.DB, groupID); errors.Is(err, errGroupFull) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 通过邀请链接加入时，由邀请链接决定是否需要审批
+	var invite *model.GroupInvite
+	needApproval := group.IsPrivate || group.NeedApproval
+	if req.InviteCode != "" {
+		invite = findValidGroupInvite(groupID, req.InviteCode)
+		if invite == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": errGroupInviteUnavailable.Error(),
+			})
+			return
+		}
+		needApproval = invite.RequireApproval
+	}
+
+	// 需要审批时检查入群问题的回答，开启自动通过且全部答对时直接加入
+	var answers string
+	if needApproval {
+		if questions := getGroupQuestions(groupID); len(questions) > 0 {
+			submitted := make(map[uint64]string, len(req.Answers))
+			for _, answer := range req.Answers {
+				submitted[answer.QuestionID] = answer.Answer
+			}
+
+			graded, allCorrect, ok := gradeGroupAnswers(questions, submitted)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "请回答所有入群问题",
+				})
+				return
+			}
+
+			if group.AutoApprove && allCorrect {
+				needApproval = false
+			} else {
+				data, _ := json.Marshal(graded)
+				answers = string(data)
+			}
+		}
+	}
+
 	// 如果群聊是私有的或需要审批，创建申请
-	if group.IsPrivate || group.NeedApproval {
+	if needApproval {
 		// 检查是否已有申请
 		var existingRequest model.GroupRequest
-		result = repository.DB.Where("user_id = ? AND group_id = ? AND status = ?", 
-			currentUserID.(uint64), groupID, "pending").First(&existingRequest)
+		result = repository.DB.Where("user_id = ? AND group_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)", 
+			currentUserID.(uint64), groupID, "pending", time.Now()).First(&existingRequest)
 		if result.Error == nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "已有加入群聊的申请，请等待审批",
@@ -267,14 +378,35 @@ func JoinGroup(c *gin.Context) {
 
 		// 创建群聊申请
 		request := model.GroupRequest{
-			UserID:  currentUserID.(uint64),
-			GroupID: groupID,
-			Message: "申请加入群聊",
-			Status:  "pending",
+			UserID:    currentUserID.(uint64),
+			GroupID:   groupID,
+			Message:   "申请加入群聊",
+			Answers:   answers,
+			Status:    "pending",
+			ExpiresAt: groupRequestExpiresAt(&group),
+		}
+		if req.Message != "" {
+			request.Message = req.Message
+		}
+		if invite != nil {
+			request.InviteID = invite.ID
 		}
 
-		result = repository.DB.Create(&request)
-		if result.Error != nil {
+		err = repository.DB.Transaction(func(tx *gorm.DB) error {
+			if invite != nil {
+				if err := redeemGroupInvite(tx, invite, currentUserID.(uint64), "requested"); err != nil {
+					return err
+				}
+			}
+			return tx.Create(&request).Error
+		})
+		if errors.Is(err, errGroupInviteUnavailable) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "提交申请失败",
 			})
@@ -295,8 +427,24 @@ func JoinGroup(c *gin.Context) {
 			JoinedAt: time.Now(),
 		}
 
-		result = repository.DB.Create(&member)
-		if result.Error != nil {
+		err = repository.DB.Transaction(func(tx *gorm.DB) error {
+			if err := checkGroupCapacity(tx, groupID); err != nil {
+				return err
+			}
+			if invite != nil {
+				if err := redeemGroupInvite(tx, invite, currentUserID.(uint64), "joined"); err != nil {
+					return err
+				}
+			}
+			return tx.Create(&member).Error
+		})
+		if errors.Is(err, errGroupInviteUnavailable) || errors.Is(err, errGroupFull) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "加入群聊失败",
 			})
@@ -339,29 +487,62 @@ func LeaveGroup(c *gin.Context) {
 		return
 	}
 
-	// 如果用户是群主，不允许退出（需要先转让群聊）
-	if groupMember.Role == "owner" {
+	// 群主退出时自动将群聊转让给任职最久的管理员，没有管理员时需要先转让或解散群聊
+	var newOwner *model.GroupMember
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if groupMember.Role == "owner" {
+			var err error
+			if newOwner, err = transferOwnershipOnLeave(tx, &groupMember); err != nil {
+				return err
+			}
+			recordGroupAudit(tx, groupID, currentUserID.(uint64), AuditOwnerLeave, newOwner.UserID,
+				gin.H{"owner_id": currentUserID.(uint64)}, gin.H{"owner_id": newOwner.UserID}, "")
+		}
+
+		// 删除群成员记录及其频道数据
+		if err := removeGroupChannelData(tx, groupID, currentUserID.(uint64)); err != nil {
+			return err
+		}
+		return tx.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).Delete(&model.GroupMember{}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "群主不能直接退出群聊，请先转让群聊",
+			"error": "群内没有管理员可接任群主，请先转让群聊或解散群聊",
 		})
 		return
 	}
-
-	// 删除群成员记录
-	result = repository.DB.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).Delete(&model.GroupMember{})
-	if result.Error != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "退出群聊失败",
 		})
 		return
 	}
 
+	Manager.RemoveUserFromGroup(groupID, currentUserID.(uint64))
+
+	if newOwner != nil {
+		notifyGroupMembers(&Manager, groupID, WebSocketMessage{
+			Type: "group_owner_changed",
+			Payload: gin.H{
+				"group_id":     groupID,
+				"old_owner_id": currentUserID.(uint64),
+				"new_owner_id": newOwner.UserID,
+			},
+			Timestamp: time.Now(),
+		})
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "已退出群聊，群主已转让给管理员",
+			"new_owner_id": newOwner.UserID,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "已退出群聊",
 	})
 }
 
-// GetGroupRequests 获取群聊申请列表
+// GetGroupRequests 获取群聊申请列表，支持按状态、申请日期和申请人筛选
 func GetGroupRequests(c *gin.Context) {
 	groupIDStr := c.Param("group_id")
 	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
@@ -381,10 +562,9 @@ func GetGroupRequests(c *gin.Context) {
 		return
 	}
 
-	// 检查用户是否是群主或管理员
-	var groupMember model.GroupMember
-	result := repository.DB.Where("group_id = ? AND user_id = ? AND role IN ?", groupID, currentUserID.(uint64), []string{"owner", "admin"}).First(&groupMember)
-	if result.Error != nil {
+	// 检查用户是否有管理群成员的权限
+	_, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "您没有权限查看群聊申请",
 		})
@@ -393,7 +573,14 @@ func GetGroupRequests(c *gin.Context) {
 
 	// 获取群聊申请列表
 	var requests []model.GroupRequest
-	err = repository.DB.Where("group_id = ? AND status = ?", groupID, "pending").Find(&requests).Error
+	query, ok := filterGroupRequests(c, repository.DB.Where("group_id = ?", groupID))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的筛选条件",
+		})
+		return
+	}
+	err = query.Order("created_at DESC").Find(&requests).Error
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "获取群聊申请列表失败",
@@ -410,6 +597,12 @@ func GetGroupRequests(c *gin.Context) {
 			continue // 跳过找不到的用户
 		}
 
+		// 已过期但尚未被定时任务标记的申请按已过期展示
+		status := request.Status
+		if status == "pending" && !isGroupRequestPending(&request) {
+			status = "expired"
+		}
+
 		requestData = append(requestData, gin.H{
 			"id":      request.ID,
 			"user_id": user.ID,
@@ -417,7 +610,15 @@ func GetGroupRequests(c *gin.Context) {
 			"nickname": user.Nickname,
 			"avatar_url": user.AvatarURL,
 			"message": request.Message,
+			"invite_id": request.InviteID,
+			"inviter_id": request.InviterID,
+			"answers": decodeGroupAnswers(&request),
+			"status": status,
+			"handled_by": request.HandledBy,
+			"handled_message": request.HandledMessage,
+			"handled_at": request.HandledAt,
 			"created_at": request.CreatedAt,
+			"expires_at": request.ExpiresAt,
 		})
 	}
 
@@ -468,63 +669,29 @@ func HandleGroupRequest(c *gin.Context) {
 		return
 	}
 
-	// 检查用户是否是群主或管理员
-	var groupMember model.GroupMember
-	result = repository.DB.Where("group_id = ? AND user_id = ? AND role IN ?", 
-		request.GroupID, currentUserID.(uint64), []string{"owner", "admin"}).First(&groupMember)
-	if result.Error != nil {
+	// 检查用户是否有管理群成员的权限
+	_, ok := checkGroupPermission(request.GroupID, currentUserID.(uint64), PermManageMembers)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "您没有权限处理该申请",
 		})
 		return
 	}
 
-	// 更新申请状态
-	status := "rejected"
-	if req.Action == "accept" {
-		status = "accepted"
+	err = processGroupRequest(request.ID, currentUserID.(uint64), req.Action == "accept", req.Reason)
+	if errors.Is(err, errGroupRequestClosed) || errors.Is(err, errGroupRequestBanned) || errors.Is(err, errGroupFull) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
-
-	request.Status = status
-	request.HandledBy = currentUserID.(uint64)
-	request.HandledMessage = req.Reason
-	request.HandledAt = &time.Time{}
-	*request.HandledAt = time.Now()
-
-	result = repository.DB.Save(&request)
-	if result.Error != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "处理申请失败",
 		})
 		return
 	}
 
-	// 如果是接受申请，添加用户到群聊
-	if req.Action == "accept" {
-		// 检查用户是否已经是群成员
-		var existingMember model.GroupMember
-		result = repository.DB.Where("group_id = ? AND user_id = ?", request.GroupID, request.UserID).First(&existingMember)
-		if result.Error != nil {
-			// 添加用户到群聊
-			member := model.GroupMember{
-				GroupID:  request.GroupID,
-				UserID:   request.UserID,
-				Role:     "member",
-				Unread:   0,
-				IsMute:   false,
-				JoinedAt: time.Now(),
-			}
-
-			result = repository.DB.Create(&member)
-			if result.Error != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": "添加群成员失败",
-				})
-				return
-			}
-		}
-	}
-
 	actionText := "拒绝"
 	if req.Action == "accept" {
 		actionText = "接受"
@@ -553,6 +720,8 @@ func UpdateGroupInfo(c *gin.Context) {
 		Announcement string `json:"announcement"`
 		NeedApproval *bool  `json:"need_approval"`
 		IsPrivate    *bool  `json:"is_private"`
+
+		InvitePermission string `json:"invite_permission" binding:"omitempty,oneof=anyone admins"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -571,11 +740,9 @@ func UpdateGroupInfo(c *gin.Context) {
 		return
 	}
 
-	// 检查用户是否是群主或管理员
-	var groupMember model.GroupMember
-	result := repository.DB.Where("group_id = ? AND user_id = ? AND role IN ?", 
-		groupID, currentUserID.(uint64), []string{"owner", "admin"}).First(&groupMember)
-	if result.Error != nil {
+	// 检查用户是否有修改群聊信息的权限
+	_, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermEditInfo)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "您没有权限修改群聊信息",
 		})
@@ -584,7 +751,7 @@ func UpdateGroupInfo(c *gin.Context) {
 
 	// 更新群聊信息
 	var group model.Group
-	result = repository.DB.Where("id = ?", groupID).First(&group)
+	result := repository.DB.Where("id = ?", groupID).First(&group)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "群聊不存在",
@@ -592,6 +759,8 @@ func UpdateGroupInfo(c *gin.Context) {
 		return
 	}
 
+	before := groupInfoAuditValue(&group)
+
 	// 只更新非空字段
 	if req.Name != "" {
 		group.Name = req.Name
@@ -602,24 +771,44 @@ func UpdateGroupInfo(c *gin.Context) {
 	if req.AvatarURL != "" {
 		group.AvatarURL = req.AvatarURL
 	}
-	if req.Announcement != "" {
-		group.Announcement = req.Announcement
-	}
+	// 公告变更时发布一条新的公告记录
+	publishAnnouncement := req.Announcement != "" && req.Announcement != group.Announcement
 	if req.NeedApproval != nil {
 		group.NeedApproval = *req.NeedApproval
 	}
 	if req.IsPrivate != nil {
 		group.IsPrivate = *req.IsPrivate
 	}
+	if req.InvitePermission != "" {
+		group.InvitePermission = req.InvitePermission
+	}
 
-	result = repository.DB.Save(&group)
-	if result.Error != nil {
+	var announcement *model.GroupAnnouncement
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&group).Error; err != nil {
+			return err
+		}
+		if publishAnnouncement {
+			var err error
+			if announcement, err = createGroupAnnouncement(tx, groupID, currentUserID.(uint64), req.Announcement, false); err != nil {
+				return err
+			}
+			group.Announcement = announcement.Content
+		}
+		recordGroupAudit(tx, groupID, currentUserID.(uint64), AuditUpdateInfo, groupID, before, groupInfoAuditValue(&group), "")
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "更新群聊信息失败",
 		})
 		return
 	}
 
+	if announcement != nil {
+		notifyGroupAnnouncement(&Manager, announcement)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "群聊信息更新成功",
 		"group":   group,
@@ -717,6 +906,9 @@ func TransferGroup(c *gin.Context) {
 		return
 	}
 
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditTransfer, req.NewOwnerID,
+		gin.H{"owner_id": currentUserID.(uint64)}, gin.H{"owner_id": req.NewOwnerID}, "")
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "群聊转让成功",
 	})
@@ -735,6 +927,7 @@ func RemoveGroupMember(c *gin.Context) {
 
 	var req struct {
 		UserID uint64 `json:"user_id" binding:"required"`
+		Reason string `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -753,11 +946,9 @@ func RemoveGroupMember(c *gin.Context) {
 		return
 	}
 
-	// 检查用户是否是群主或管理员
-	var groupMember model.GroupMember
-	result := repository.DB.Where("group_id = ? AND user_id = ? AND role IN ?", 
-		groupID, currentUserID.(uint64), []string{"owner", "admin"}).First(&groupMember)
-	if result.Error != nil {
+	// 检查用户是否有管理群成员的权限
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "您没有权限移除群成员",
 		})
@@ -766,7 +957,7 @@ func RemoveGroupMember(c *gin.Context) {
 
 	// 不能移除群主
 	var targetMember model.GroupMember
-	result = repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).First(&targetMember)
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).First(&targetMember)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "目标用户不是群成员",
@@ -781,17 +972,27 @@ func RemoveGroupMember(c *gin.Context) {
 		return
 	}
 
-	// 如果操作者不是群主，不能移除管理员
-	if groupMember.Role == "admin" && targetMember.Role == "admin" {
+	// 只能移除级别低于自己的成员
+	if !outranksGroupMember(groupMember, &targetMember) {
 		c.JSON(http.StatusForbidden, gin.H{
-			"error": "管理员不能移除其他管理员",
+			"error": "不能移除与您同级或级别更高的成员",
 		})
 		return
 	}
 
-	// 删除群成员
-	result = repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).Delete(&model.GroupMember{})
-	if result.Error != nil {
+	// 删除群成员及其频道数据
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := removeGroupChannelData(tx, groupID, req.UserID); err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ? AND user_id = ?", groupID, req.UserID).Delete(&model.GroupMember{}).Error; err != nil {
+			return err
+		}
+		recordGroupAudit(tx, groupID, currentUserID.(uint64), AuditRemoveMember, req.UserID,
+			gin.H{"role": targetMember.Role}, nil, req.Reason)
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "移除群成员失败",
 		})
@@ -835,11 +1036,9 @@ func SetGroupMemberRole(c *gin.Context) {
 		return
 	}
 
-	// 检查用户是否是群主或管理员
-	var groupMember model.GroupMember
-	result := repository.DB.Where("group_id = ? AND user_id = ? AND role IN ?", 
-		groupID, currentUserID.(uint64), []string{"owner", "admin"}).First(&groupMember)
-	if result.Error != nil {
+	// 检查用户是否有管理群聊角色的权限
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageRoles)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "您没有权限设置群成员角色",
 		})
@@ -848,7 +1047,7 @@ func SetGroupMemberRole(c *gin.Context) {
 
 	// 不能修改群主角色
 	var targetMember model.GroupMember
-	result = repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).First(&targetMember)
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).First(&targetMember)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "目标用户不是群成员",
@@ -863,15 +1062,24 @@ func SetGroupMemberRole(c *gin.Context) {
 		return
 	}
 
-	// 如果操作者不是群主，不能修改管理员角色
-	if groupMember.Role == "admin" && targetMember.Role == "admin" {
+	// 只能修改级别低于自己的成员的角色
+	if !outranksGroupMember(groupMember, &targetMember) {
 		c.JSON(http.StatusForbidden, gin.H{
-			"error": "管理员不能修改其他管理员角色",
+			"error": "不能修改与您同级或级别更高的成员的角色",
+		})
+		return
+	}
+
+	// 只有群主和管理员可以设置管理员
+	if req.Role == "admin" && groupMember.Role != "owner" && groupMember.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "只有群主和管理员可以设置管理员",
 		})
 		return
 	}
 
 	// 更新角色
+	oldRole := targetMember.Role
 	targetMember.Role = req.Role
 	result = repository.DB.Save(&targetMember)
 	if result.Error != nil {
@@ -881,6 +1089,9 @@ func SetGroupMemberRole(c *gin.Context) {
 		return
 	}
 
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditSetRole, targetMember.UserID,
+		gin.H{"role": oldRole}, gin.H{"role": targetMember.Role}, "")
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "群成员角色更新成功",
 		"member":  targetMember,
@@ -899,12 +1110,13 @@ func SetGroupMute(c *gin.Context) {
 	}
 
 	var req struct {
-		UserID uint64 `json:"user_id" binding:"required"`
-		Mute   bool   `json:"mute" binding:"required"`
-		Reason string `json:"reason"`
+		UserID   uint64 `json:"user_id" binding:"required"`
+		Mute     *bool  `json:"mute" binding:"required"`
+		Reason   string `json:"reason"`
+		Duration int64  `json:"duration"` // 禁言时长（秒），为 0 表示永久禁言
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil || req.Duration < 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "请求数据格式错误",
 		})
@@ -920,11 +1132,9 @@ func SetGroupMute(c *gin.Context) {
 		return
 	}
 
-	// 检查用户是否是群主或管理员
-	var groupMember model.GroupMember
-	result := repository.DB.Where("group_id = ? AND user_id = ? AND role IN ?", 
-		groupID, currentUserID.(uint64), []string{"owner", "admin"}).First(&groupMember)
-	if result.Error != nil {
+	// 检查用户是否有禁言权限
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermMute)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "您没有权限设置群成员禁言",
 		})
@@ -933,7 +1143,7 @@ func SetGroupMute(c *gin.Context) {
 
 	// 检查目标用户是否是群成员
 	var targetMember model.GroupMember
-	result = repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).First(&targetMember)
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).First(&targetMember)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "目标用户不是群成员",
@@ -941,25 +1151,37 @@ func SetGroupMute(c *gin.Context) {
 		return
 	}
 
-	// 如果操作者不是群主，不能对管理员进行禁言
-	if groupMember.Role == "admin" && targetMember.Role == "admin" {
+	// 不能对群主进行禁言
+	if targetMember.Role == "owner" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "不能对群主进行禁言",
+		})
+		return
+	}
+
+	// 只能禁言级别低于自己的成员
+	if !outranksGroupMember(groupMember, &targetMember) {
 		c.JSON(http.StatusForbidden, gin.H{
-			"error": "管理员不能对其他管理员进行禁言",
+			"error": "不能对与您同级或级别更高的成员进行禁言",
 		})
 		return
 	}
 
 	// 设置禁言
-	targetMember.IsMute = req.Mute
-	if req.Mute {
+	before := muteAuditValue(targetMember.IsMute, targetMember.MutePermanent, targetMember.MuteTexpires)
+	targetMember.IsMute = *req.Mute
+	if *req.Mute {
 		now := time.Now()
 		targetMember.MuteAt = &now
 		targetMember.MuteReason = req.Reason
 		targetMember.MuteBy = currentUserID.(uint64)
+		targetMember.MuteTexpires, targetMember.MutePermanent = muteUntil(req.Duration)
 	} else {
 		targetMember.MuteAt = nil
 		targetMember.MuteReason = ""
 		targetMember.MuteBy = 0
+		targetMember.MuteTexpires = nil
+		targetMember.MutePermanent = false
 	}
 
 	result = repository.DB.Save(&targetMember)
@@ -970,13 +1192,26 @@ func SetGroupMute(c *gin.Context) {
 		return
 	}
 
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditMuteMember, targetMember.UserID, before,
+		muteAuditValue(targetMember.IsMute, targetMember.MutePermanent, targetMember.MuteTexpires), req.Reason)
+
+	notifyGroupMute(&Manager, GroupMutePayload{
+		GroupID:   groupID,
+		UserID:    targetMember.UserID,
+		IsMute:    targetMember.IsMute,
+		Permanent: targetMember.MutePermanent,
+		MuteUntil: targetMember.MuteTexpires,
+		Reason:    targetMember.MuteReason,
+	})
+
 	actionText := "解除"
-	if req.Mute {
+	if *req.Mute {
 		actionText = "设置"
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": actionText + "群成员禁言成功",
+		"member":  targetMember,
 	})
 }
 
@@ -1028,6 +1263,7 @@ func SearchGroups(c *gin.Context) {
 			"description":   group.Description,
 			"need_approval": group.NeedApproval,
 			"member_count":  memberCount,
+			"max_members":   groupMemberLimit(&group),
 			"created_at":    group.CreatedAt,
 			"joined":        joined, // 标识用户是否已加入该群聊
 		})
@@ -0,0 +1,360 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 群公告列表每页数量
+const groupAnnouncementPageSize = 20
+
+// createGroupAnnouncement 在事务中发布群公告，并同步更新群聊的当前公告
+func createGroupAnnouncement(tx *gorm.DB, groupID, authorID uint64, content string, requireAck bool) (*model.GroupAnnouncement, error) {
+	announcement := model.GroupAnnouncement{
+		GroupID:    groupID,
+		Content:    content,
+		AuthorID:   authorID,
+		RequireAck: requireAck,
+		CreatedAt:  time.Now(),
+	}
+	if err := tx.Create(&announcement).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&model.Group{}).Where("id = ?", groupID).Update("announcement", content).Error; err != nil {
+		return nil, err
+	}
+	return &announcement, nil
+}
+
+// notifyGroupAnnouncement 向群成员推送新公告
+func notifyGroupAnnouncement(manager *ClientManager, announcement *model.GroupAnnouncement) {
+	notifyGroupMembers(manager, announcement.GroupID, WebSocketMessage{
+		Type:      "group_announcement",
+		Payload:   announcement,
+		Timestamp: time.Now(),
+	})
+}
+
+// findGroupAnnouncement 根据路径参数查询群公告，查询失败时写入错误响应
+func findGroupAnnouncement(c *gin.Context, groupID uint64) (*model.GroupAnnouncement, bool) {
+	announcementID, err := strconv.ParseUint(c.Param("announcement_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的公告ID",
+		})
+		return nil, false
+	}
+
+	var announcement model.GroupAnnouncement
+	if err := repository.DB.Where("id = ? AND group_id = ?", announcementID, groupID).First(&announcement).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "公告不存在",
+		})
+		return nil, false
+	}
+	return &announcement, true
+}
+
+// PublishGroupAnnouncement 发布群公告
+func PublishGroupAnnouncement(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		Content    string `json:"content" binding:"required,max=5000"`
+		RequireAck bool   `json:"require_ack"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有修改群聊信息的权限
+	if _, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermEditInfo); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限发布群公告",
+		})
+		return
+	}
+
+	var announcement *model.GroupAnnouncement
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if announcement, err = createGroupAnnouncement(tx, groupID, currentUserID.(uint64), req.Content, req.RequireAck); err != nil {
+			return err
+		}
+		recordGroupAudit(tx, groupID, currentUserID.(uint64), AuditPublishAnnouncement, announcement.ID, nil, gin.H{
+			"content":     announcement.Content,
+			"require_ack": announcement.RequireAck,
+		}, "")
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "发布群公告失败",
+		})
+		return
+	}
+
+	notifyGroupAnnouncement(&Manager, announcement)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "群公告发布成功",
+		"announcement": announcement,
+	})
+}
+
+// GetGroupAnnouncements 获取群公告历史，按发布时间倒序分页
+func GetGroupAnnouncements(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否是群成员
+	var groupMember model.GroupMember
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).First(&groupMember)
+	if result.Error != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您不是该群聊的成员",
+		})
+		return
+	}
+
+	var total int64
+	repository.DB.Model(&model.GroupAnnouncement{}).Where("group_id = ?", groupID).Count(&total)
+
+	var announcements []model.GroupAnnouncement
+	err = repository.DB.Where("group_id = ?", groupID).Order("created_at DESC, id DESC").
+		Offset((page - 1) * groupAnnouncementPageSize).Limit(groupAnnouncementPageSize).
+		Find(&announcements).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取群公告失败",
+		})
+		return
+	}
+
+	// 当前用户已确认的公告
+	announcementIDs := make([]uint64, len(announcements))
+	for i, announcement := range announcements {
+		announcementIDs[i] = announcement.ID
+	}
+	acked := make(map[uint64]bool)
+	if len(announcementIDs) > 0 {
+		var ackedIDs []uint64
+		repository.DB.Model(&model.GroupAnnouncementAck{}).
+			Where("announcement_id IN ? AND user_id = ?", announcementIDs, currentUserID.(uint64)).
+			Pluck("announcement_id", &ackedIDs)
+		for _, id := range ackedIDs {
+			acked[id] = true
+		}
+	}
+
+	announcementData := make([]gin.H, 0, len(announcements))
+	for _, announcement := range announcements {
+		var author model.User
+		repository.DB.Select("id, username, nickname, avatar_url").Where("id = ?", announcement.AuthorID).First(&author)
+
+		item := gin.H{
+			"id":          announcement.ID,
+			"content":     announcement.Content,
+			"author_id":   announcement.AuthorID,
+			"author_name": author.Nickname,
+			"require_ack": announcement.RequireAck,
+			"acked":       acked[announcement.ID],
+			"created_at":  announcement.CreatedAt,
+		}
+		if author.Nickname == "" {
+			item["author_name"] = author.Username
+		}
+		if announcement.RequireAck {
+			var ackCount int64
+			repository.DB.Model(&model.GroupAnnouncementAck{}).Where("announcement_id = ?", announcement.ID).Count(&ackCount)
+			item["ack_count"] = ackCount
+		}
+		announcementData = append(announcementData, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"announcements": announcementData,
+		"total":         total,
+		"page":          page,
+	})
+}
+
+// AckGroupAnnouncement 确认已读群公告
+func AckGroupAnnouncement(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否是群成员
+	var groupMember model.GroupMember
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).First(&groupMember)
+	if result.Error != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您不是该群聊的成员",
+		})
+		return
+	}
+
+	announcement, ok := findGroupAnnouncement(c, groupID)
+	if !ok {
+		return
+	}
+
+	ack := model.GroupAnnouncementAck{
+		AnnouncementID: announcement.ID,
+		GroupID:        groupID,
+		UserID:         currentUserID.(uint64),
+		AckedAt:        time.Now(),
+	}
+	err = repository.DB.Where("announcement_id = ? AND user_id = ?", announcement.ID, currentUserID.(uint64)).
+		FirstOrCreate(&ack).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "确认群公告失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "已确认群公告",
+		"acked_at": ack.AckedAt,
+	})
+}
+
+// GetGroupAnnouncementAcks 查看群公告的确认情况，列出已确认和未确认的成员
+func GetGroupAnnouncementAcks(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有修改群聊信息的权限
+	if _, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermEditInfo); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限查看公告确认情况",
+		})
+		return
+	}
+
+	announcement, ok := findGroupAnnouncement(c, groupID)
+	if !ok {
+		return
+	}
+
+	var acks []model.GroupAnnouncementAck
+	repository.DB.Where("announcement_id = ?", announcement.ID).Find(&acks)
+	ackedAt := make(map[uint64]time.Time, len(acks))
+	for _, ack := range acks {
+		ackedAt[ack.UserID] = ack.AckedAt
+	}
+
+	var members []model.GroupMember
+	if err := repository.DB.Where("group_id = ?", groupID).Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取群成员失败",
+		})
+		return
+	}
+
+	// 按当前群成员区分已确认和未确认
+	ackedMembers := []gin.H{}
+	pendingMembers := []gin.H{}
+	for _, member := range members {
+		var user model.User
+		if repository.DB.Select("id, username, nickname, avatar_url").Where("id = ?", member.UserID).First(&user).Error != nil {
+			continue
+		}
+		item := gin.H{
+			"user_id":    user.ID,
+			"username":   user.Username,
+			"nickname":   user.Nickname,
+			"avatar_url": user.AvatarURL,
+		}
+		if at, ok := ackedAt[member.UserID]; ok {
+			item["acked_at"] = at
+			ackedMembers = append(ackedMembers, item)
+		} else {
+			pendingMembers = append(pendingMembers, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"announcement": announcement,
+		"acked":        ackedMembers,
+		"pending":      pendingMembers,
+	})
+}
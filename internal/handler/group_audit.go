@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 群聊审计日志操作类型
+const (
+	AuditUpdateInfo          = "update_info"
+	AuditTransfer            = "transfer"
+	AuditDissolve            = "dissolve"
+	AuditOwnerLeave          = "owner_leave"
+	AuditRemoveMember        = "remove_member"
+	AuditSetRole             = "set_role"
+	AuditMuteMember          = "mute_member"
+	AuditMuteAll             = "mute_all"
+	AuditHandleRequest       = "handle_request"
+	AuditBan                 = "ban"
+	AuditUnban               = "unban"
+	AuditCreateRole          = "create_role"
+	AuditUpdateRole          = "update_role"
+	AuditDeleteRole          = "delete_role"
+	AuditAssignRole          = "assign_role"
+	AuditCreateInvite        = "create_invite"
+	AuditRevokeInvite        = "revoke_invite"
+	AuditSetQuestions        = "set_questions"
+	AuditSetCapacity         = "set_capacity"
+	AuditCreateChannel       = "create_channel"
+	AuditUpdateChannel       = "update_channel"
+	AuditDeleteChannel       = "delete_channel"
+	AuditSetChannelMembers   = "set_channel_members"
+	AuditPublishAnnouncement = "publish_announcement"
+	AuditSetSlowMode         = "set_slow_mode"
+)
+
+// 审计日志每页数量
+const groupAuditLogPageSize = 50
+
+// encodeAuditValue 将审计值序列化为JSON，空值返回空字符串
+func encodeAuditValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// groupInfoAuditValue 记录群聊信息中可被修改的字段
+func groupInfoAuditValue(group *model.Group) gin.H {
+	return gin.H{
+		"name":              group.Name,
+		"description":       group.Description,
+		"avatar_url":        group.AvatarURL,
+		"announcement":      group.Announcement,
+		"need_approval":     group.NeedApproval,
+		"is_private":        group.IsPrivate,
+		"invite_permission": group.InvitePermission,
+	}
+}
+
+// muteAuditValue 记录禁言状态
+func muteAuditValue(isMute, permanent bool, expires *time.Time) gin.H {
+	return gin.H{
+		"is_mute":        isMute,
+		"mute_permanent": permanent,
+		"mute_texpires":  expires,
+	}
+}
+
+// roleAuditValue 记录自定义角色的名称和权限
+func roleAuditValue(role *model.GroupRole) gin.H {
+	return gin.H{
+		"name":        role.Name,
+		"permissions": decodeGroupRolePermissions(role),
+	}
+}
+
+// questionsAuditValue 记录入群问题和申请审核规则
+func questionsAuditValue(group *model.Group, questions []model.GroupQuestion) gin.H {
+	items := make([]gin.H, len(questions))
+	for i, question := range questions {
+		items[i] = gin.H{"question": question.Question, "answer": question.Answer}
+	}
+	return gin.H{
+		"questions":    items,
+		"auto_approve": group.AutoApprove,
+		"request_ttl":  group.RequestTTL,
+	}
+}
+
+// channelAuditValue 记录频道设置
+func channelAuditValue(channel *model.GroupChannel) gin.H {
+	return gin.H{
+		"name":          channel.Name,
+		"description":   channel.Description,
+		"is_restricted": channel.IsRestricted,
+		"sort_order":    channel.SortOrder,
+	}
+}
+
+// recordGroupAudit 记录群聊管理操作，可传入事务使日志与操作一同提交
+// 记录失败只写错误日志，不影响操作本身
+func recordGroupAudit(db *gorm.DB, groupID, actorID uint64, action string, targetID uint64, before, after interface{}, reason string) {
+	log := model.GroupAuditLog{
+		GroupID:  groupID,
+		ActorID:  actorID,
+		Action:   action,
+		TargetID: targetID,
+		Before:   encodeAuditValue(before),
+		After:    encodeAuditValue(after),
+		Reason:   reason,
+	}
+	if err := db.Create(&log).Error; err != nil {
+		utils.Errorf("记录群聊审计日志失败: %v", err)
+	}
+}
+
+// GetGroupAuditLogs 获取群聊管理操作审计日志，仅群主和管理员可查看
+// 支持按操作类型 action 和操作人 actor_id 筛选，按时间倒序分页
+func GetGroupAuditLogs(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 审计日志涉及所有管理操作，需要管理角色的权限
+	if _, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageRoles); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限查看审计日志",
+		})
+		return
+	}
+
+	query := repository.DB.Model(&model.GroupAuditLog{}).Where("group_id = ?", groupID)
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if actorIDStr := c.Query("actor_id"); actorIDStr != "" {
+		actorID, err := strconv.ParseUint(actorIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的操作人ID",
+			})
+			return
+		}
+		query = query.Where("actor_id = ?", actorID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var logs []model.GroupAuditLog
+	err = query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * groupAuditLogPageSize).Limit(groupAuditLogPageSize).
+		Find(&logs).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取审计日志失败",
+		})
+		return
+	}
+
+	// 批量查询操作人信息
+	actorIDs := make([]uint64, 0, len(logs))
+	for _, log := range logs {
+		actorIDs = append(actorIDs, log.ActorID)
+	}
+	names := make(map[uint64]string)
+	if len(actorIDs) > 0 {
+		var users []model.User
+		repository.DB.Select("id, username, nickname").Where("id IN ?", actorIDs).Find(&users)
+		for _, user := range users {
+			names[user.ID] = user.Nickname
+			if user.Nickname == "" {
+				names[user.ID] = user.Username
+			}
+		}
+	}
+
+	logData := make([]gin.H, len(logs))
+	for i, log := range logs {
+		logData[i] = gin.H{
+			"id":         log.ID,
+			"actor_id":   log.ActorID,
+			"actor_name": names[log.ActorID],
+			"action":     log.Action,
+			"target_id":  log.TargetID,
+			"before":     json.RawMessage(nullIfEmpty(log.Before)),
+			"after":      json.RawMessage(nullIfEmpty(log.After)),
+			"reason":     log.Reason,
+			"created_at": log.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":  logData,
+		"total": total,
+		"page":  page,
+	})
+}
+
+// nullIfEmpty 空字符串转换为 JSON null
+func nullIfEmpty(value string) string {
+	if value == "" {
+		return "null"
+	}
+	return value
+}
@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findGroupBan 查询用户在群聊中当前生效的封禁记录，未被封禁时返回 nil
+func findGroupBan(groupID, userID uint64) *model.GroupBan {
+	var ban model.GroupBan
+	err := repository.DB.Where("group_id = ? AND user_id = ? AND (expires_at IS NULL OR expires_at > ?)",
+		groupID, userID, time.Now()).First(&ban).Error
+	if err != nil {
+		return nil
+	}
+	return &ban
+}
+
+// bannedGroupUserIDs 从候选用户中筛选出被群聊封禁的用户
+func bannedGroupUserIDs(groupID uint64, userIDs []uint64) map[uint64]bool {
+	banned := make(map[uint64]bool)
+	if len(userIDs) == 0 {
+		return banned
+	}
+
+	var ids []uint64
+	repository.DB.Model(&model.GroupBan{}).
+		Where("group_id = ? AND user_id IN ? AND (expires_at IS NULL OR expires_at > ?)", groupID, userIDs, time.Now()).
+		Pluck("user_id", &ids)
+	for _, id := range ids {
+		banned[id] = true
+	}
+	return banned
+}
+
+// BanGroupMember 将用户加入群聊黑名单，可同时将其移出群聊
+func BanGroupMember(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		UserID   uint64 `json:"user_id" binding:"required"`
+		Reason   string `json:"reason"`
+		Duration int64  `json:"duration"` // 封禁时长（秒），为 0 表示永久封禁
+		Kick     bool   `json:"kick"`     // 是否同时移出群聊
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.Duration < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	if req.UserID == currentUserID.(uint64) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "不能将自己加入黑名单",
+		})
+		return
+	}
+
+	// 检查用户是否有管理群成员的权限
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群聊黑名单",
+		})
+		return
+	}
+
+	var targetUser model.User
+	if err := repository.DB.Select("id").Where("id = ?", req.UserID).First(&targetUser).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	// 目标用户在群内时检查角色
+	var targetMember model.GroupMember
+	isMember := repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).First(&targetMember).Error == nil
+	if isMember {
+		if targetMember.Role == "owner" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "不能将群主加入黑名单",
+			})
+			return
+		}
+		if !outranksGroupMember(groupMember, &targetMember) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "不能将与您同级或级别更高的成员加入黑名单",
+			})
+			return
+		}
+	}
+
+	ban := model.GroupBan{
+		GroupID: groupID,
+		UserID:  req.UserID,
+	}
+	var expiresAt *time.Time
+	if req.Duration > 0 {
+		expires := time.Now().Add(time.Duration(req.Duration) * time.Second)
+		expiresAt = &expires
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ? AND user_id = ?", groupID, req.UserID).FirstOrInit(&ban).Error; err != nil {
+			return err
+		}
+		ban.Reason = req.Reason
+		ban.BannedBy = currentUserID.(uint64)
+		ban.ExpiresAt = expiresAt
+		ban.CreatedAt = time.Now()
+		if err := tx.Save(&ban).Error; err != nil {
+			return err
+		}
+
+		// 拒绝该用户待处理的入群申请和邀请
+		now := time.Now()
+		if err := tx.Model(&model.GroupRequest{}).
+			Where("group_id = ? AND user_id = ? AND status = 'pending'", groupID, req.UserID).
+			Updates(map[string]interface{}{
+				"status":          "rejected",
+				"handled_by":      currentUserID.(uint64),
+				"handled_message": "已被加入群聊黑名单",
+				"handled_at":      now,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.GroupInvitation{}).
+			Where("group_id = ? AND invitee_id = ? AND status = 'pending'", groupID, req.UserID).
+			Updates(map[string]interface{}{"status": "declined", "handled_at": now}).Error; err != nil {
+			return err
+		}
+
+		recordGroupAudit(tx, groupID, currentUserID.(uint64), AuditBan, req.UserID, nil, gin.H{
+			"expires_at": expiresAt,
+			"kick":       isMember && req.Kick,
+		}, req.Reason)
+
+		if isMember && req.Kick {
+			if err := removeGroupChannelData(tx, groupID, req.UserID); err != nil {
+				return err
+			}
+			return tx.Where("group_id = ? AND user_id = ?", groupID, req.UserID).Delete(&model.GroupMember{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "加入群聊黑名单失败",
+		})
+		return
+	}
+
+	if isMember && req.Kick {
+		Manager.RemoveUserFromGroup(groupID, req.UserID)
+		Manager.SendToUsers(WebSocketMessage{
+			Type: "group_removed",
+			Payload: gin.H{
+				"group_id": groupID,
+				"reason":   req.Reason,
+			},
+			Timestamp: time.Now(),
+		}, req.UserID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已将用户加入群聊黑名单",
+		"ban":     ban,
+	})
+}
+
+// GetGroupBans 获取群聊黑名单
+func GetGroupBans(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有管理群成员的权限
+	_, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限查看群聊黑名单",
+		})
+		return
+	}
+
+	var bans []model.GroupBan
+	err = repository.DB.Where("group_id = ? AND (expires_at IS NULL OR expires_at > ?)", groupID, time.Now()).
+		Order("created_at DESC").Find(&bans).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取群聊黑名单失败",
+		})
+		return
+	}
+
+	// 添加用户信息
+	banData := make([]gin.H, 0, len(bans))
+	for _, ban := range bans {
+		var user model.User
+		if repository.DB.Select("id, username, nickname, avatar_url").Where("id = ?", ban.UserID).First(&user).Error != nil {
+			continue
+		}
+		banData = append(banData, gin.H{
+			"user_id":    user.ID,
+			"username":   user.Username,
+			"nickname":   user.Nickname,
+			"avatar_url": user.AvatarURL,
+			"reason":     ban.Reason,
+			"banned_by":  ban.BannedBy,
+			"expires_at": ban.ExpiresAt,
+			"created_at": ban.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans": banData,
+	})
+}
+
+// UnbanGroupMember 将用户移出群聊黑名单
+func UnbanGroupMember(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的用户ID",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有管理群成员的权限
+	_, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群聊黑名单",
+		})
+		return
+	}
+
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupBan{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "移出群聊黑名单失败",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "该用户不在群聊黑名单中",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditUnban, userID, nil, nil, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已将用户移出群聊黑名单",
+	})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errGroupFull 群聊成员数已达上限
+var errGroupFull = errors.New("群聊成员数已达上限")
+
+// groupMemberLimit 获取群聊的最大成员数，未单独设置时使用服务器默认值
+// 返回 0 表示不限制
+func groupMemberLimit(group *model.Group) int {
+	if group.MaxMembers > 0 {
+		return group.MaxMembers
+	}
+	return utils.AppConfig.Group.MaxMembers
+}
+
+// checkGroupCapacity 在事务中检查群聊是否还能加入新成员
+// 锁定群聊行，避免并发加入时超出上限
+func checkGroupCapacity(tx *gorm.DB, groupID uint64) error {
+	var group model.Group
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, max_members").Where("id = ?", groupID).First(&group).Error; err != nil {
+		return err
+	}
+
+	limit := groupMemberLimit(&group)
+	if limit <= 0 {
+		return nil
+	}
+
+	var memberCount int64
+	if err := tx.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Count(&memberCount).Error; err != nil {
+		return err
+	}
+	if memberCount >= int64(limit) {
+		return errGroupFull
+	}
+	return nil
+}
+
+// SetGroupCapacity 设置群聊的最大成员数，仅系统管理员可操作
+// max_members 为 0 时恢复使用服务器默认值
+func SetGroupCapacity(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		MaxMembers *int `json:"max_members" binding:"required,min=0"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	if isAdmin, _ := c.Get("is_admin"); isAdmin != true {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "只有系统管理员可以设置群聊人数上限",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	before := group.MaxMembers
+	group.MaxMembers = *req.MaxMembers
+	if err := repository.DB.Model(&group).Select("max_members").Updates(&group).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "设置群聊人数上限失败",
+		})
+		return
+	}
+
+	currentUserID, _ := c.Get("user_id")
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditSetCapacity, groupID,
+		gin.H{"max_members": before}, gin.H{"max_members": group.MaxMembers}, "")
+
+	var memberCount int64
+	repository.DB.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Count(&memberCount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "群聊人数上限设置成功",
+		"member_count": memberCount,
+		"max_members":  groupMemberLimit(&group),
+	})
+}
@@ -0,0 +1,568 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 每个群聊最多创建的频道数量
+const maxGroupChannels = 50
+
+// findGroupChannel 查询群聊中的频道，不存在时返回 nil
+func findGroupChannel(groupID, channelID uint64) *model.GroupChannel {
+	var channel model.GroupChannel
+	if err := repository.DB.Where("id = ? AND group_id = ?", channelID, groupID).First(&channel).Error; err != nil {
+		return nil
+	}
+	return &channel
+}
+
+// canAccessGroupChannel 判断群成员能否访问频道
+// 非受限频道所有群成员可访问；受限频道仅拥有频道管理权限的成员和频道成员可访问
+func canAccessGroupChannel(channel *model.GroupChannel, member *model.GroupMember) bool {
+	if !channel.IsRestricted {
+		return true
+	}
+	var group model.Group
+	if err := repository.DB.Select("id, invite_permission").Where("id = ?", member.GroupID).First(&group).Error; err == nil &&
+		hasGroupPermission(&group, member, PermEditInfo) {
+		return true
+	}
+	var count int64
+	repository.DB.Model(&model.GroupChannelMember{}).Where("channel_id = ? AND user_id = ?", channel.ID, member.UserID).Count(&count)
+	return count > 0
+}
+
+// checkGroupChannelAccess 检查群成员能否访问指定频道，频道ID为 0 表示默认频道
+func checkGroupChannelAccess(member *model.GroupMember, channelID uint64) bool {
+	if channelID == 0 {
+		return true
+	}
+	channel := findGroupChannel(member.GroupID, channelID)
+	return channel != nil && canAccessGroupChannel(channel, member)
+}
+
+// accessibleGroupChannelIDs 获取群成员可以访问的频道ID，包括默认频道 0
+func accessibleGroupChannelIDs(member *model.GroupMember) []uint64 {
+	channelIDs := []uint64{0}
+	var channels []model.GroupChannel
+	repository.DB.Where("group_id = ?", member.GroupID).Find(&channels)
+	for i := range channels {
+		if canAccessGroupChannel(&channels[i], member) {
+			channelIDs = append(channelIDs, channels[i].ID)
+		}
+	}
+	return channelIDs
+}
+
+// groupChannelUserIDs 获取受限频道可访问的用户，包括拥有频道管理权限的成员和频道成员
+func groupChannelUserIDs(channel *model.GroupChannel) map[uint64]bool {
+	allowed := make(map[uint64]bool)
+
+	// 只有群主、管理员和拥有自定义角色的成员可能拥有频道管理权限
+	var group model.Group
+	if err := repository.DB.Select("id, invite_permission").Where("id = ?", channel.GroupID).First(&group).Error; err == nil {
+		var managers []model.GroupMember
+		repository.DB.Where("group_id = ? AND (role IN ? OR role_id <> 0)", channel.GroupID, []string{"owner", "admin"}).Find(&managers)
+		for i := range managers {
+			if hasGroupPermission(&group, &managers[i], PermEditInfo) {
+				allowed[managers[i].UserID] = true
+			}
+		}
+	}
+
+	var memberIDs []uint64
+	repository.DB.Model(&model.GroupChannelMember{}).Where("channel_id = ?", channel.ID).Pluck("user_id", &memberIDs)
+	for _, id := range memberIDs {
+		allowed[id] = true
+	}
+	return allowed
+}
+
+// restrictedChannelUserIDs 获取群聊消息所在频道的可访问用户
+// 消息在默认频道或非受限频道时返回 nil，表示所有群成员均可接收
+func restrictedChannelUserIDs(chatMsg ChatMessage) map[uint64]bool {
+	if chatMsg.ReceiverType != "group" || chatMsg.ChannelID == 0 {
+		return nil
+	}
+	channel := findGroupChannel(chatMsg.ReceiverID, chatMsg.ChannelID)
+	if channel == nil || !channel.IsRestricted {
+		return nil
+	}
+	return groupChannelUserIDs(channel)
+}
+
+// sendGroupChatMessage 向群聊推送与消息相关的帧，受限频道只推送给可访问的用户
+func sendGroupChatMessage(manager *ClientManager, chatMsg ChatMessage, message WebSocketMessage) {
+	if allowed := restrictedChannelUserIDs(chatMsg); allowed != nil {
+		manager.SendGroupMessageToUsers(chatMsg.ReceiverID, message, allowed)
+		return
+	}
+	manager.SendGroupMessage(chatMsg.ReceiverID, message)
+}
+
+// markGroupChannelRead 更新用户在频道中的已读位置，已读位置只前进不后退
+func markGroupChannelRead(groupID, channelID, userID, lastReadID uint64) {
+	if lastReadID == 0 {
+		return
+	}
+	repository.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "group_id"}, {Name: "channel_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_id": gorm.Expr("GREATEST(last_read_id, ?)", lastReadID),
+			"updated_at":   time.Now(),
+		}),
+	}).Create(&model.GroupChannelRead{
+		GroupID:    groupID,
+		ChannelID:  channelID,
+		UserID:     userID,
+		LastReadID: lastReadID,
+		UpdatedAt:  time.Now(),
+	})
+}
+
+// groupChannelUnread 统计用户在频道中的未读消息数
+func groupChannelUnread(groupID, channelID, userID uint64) int64 {
+	var read model.GroupChannelRead
+	repository.DB.Where("group_id = ? AND channel_id = ? AND user_id = ?", groupID, channelID, userID).First(&read)
+
+	var unread int64
+	repository.DB.Model(&model.Message{}).
+		Where("receiver_type = 'group' AND receiver_id = ? AND channel_id = ? AND id > ? AND sender_id <> ?",
+			groupID, channelID, read.LastReadID, userID).
+		Count(&unread)
+	return unread
+}
+
+// removeGroupChannelData 用户离开群聊时清除其频道成员身份和已读位置
+func removeGroupChannelData(tx *gorm.DB, groupID, userID uint64) error {
+	if err := tx.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupChannelMember{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupChannelRead{}).Error
+}
+
+// getGroupChannelManager 检查当前用户是否有管理频道的权限，返回群聊ID和当前成员
+func getGroupChannelManager(c *gin.Context) (uint64, *model.GroupMember, bool) {
+	groupID, err := strconv.ParseUint(c.Param("group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return 0, nil, false
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return 0, nil, false
+	}
+
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermEditInfo)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群聊频道",
+		})
+		return 0, nil, false
+	}
+
+	return groupID, groupMember, true
+}
+
+// GetGroupChannels 获取群聊中当前用户可访问的频道及未读消息数
+// 频道ID为 0 的条目表示群聊的默认频道
+func GetGroupChannels(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否是群成员
+	var groupMember model.GroupMember
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).First(&groupMember)
+	if result.Error != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您不是该群聊的成员",
+		})
+		return
+	}
+
+	var channels []model.GroupChannel
+	err = repository.DB.Where("group_id = ?", groupID).Order("sort_order ASC, id ASC").Find(&channels).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取频道列表失败",
+		})
+		return
+	}
+
+	channelData := []gin.H{{
+		"id":            0,
+		"name":          "general",
+		"is_restricted": false,
+		"unread":        groupChannelUnread(groupID, 0, groupMember.UserID),
+	}}
+	for _, channel := range channels {
+		if !canAccessGroupChannel(&channel, &groupMember) {
+			continue
+		}
+		item := gin.H{
+			"id":            channel.ID,
+			"name":          channel.Name,
+			"description":   channel.Description,
+			"is_restricted": channel.IsRestricted,
+			"sort_order":    channel.SortOrder,
+			"unread":        groupChannelUnread(groupID, channel.ID, groupMember.UserID),
+			"created_at":    channel.CreatedAt,
+		}
+		if channel.IsRestricted {
+			var memberIDs []uint64
+			repository.DB.Model(&model.GroupChannelMember{}).Where("channel_id = ?", channel.ID).Pluck("user_id", &memberIDs)
+			item["member_ids"] = memberIDs
+		}
+		channelData = append(channelData, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channels": channelData,
+	})
+}
+
+// CreateGroupChannel 创建群聊频道
+func CreateGroupChannel(c *gin.Context) {
+	var req struct {
+		Name         string   `json:"name" binding:"required,max=50"`
+		Description  string   `json:"description" binding:"max=255"`
+		IsRestricted bool     `json:"is_restricted"`
+		SortOrder    int      `json:"sort_order"`
+		MemberIDs    []uint64 `json:"member_ids"` // 受限频道的初始成员
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	groupID, groupMember, ok := getGroupChannelManager(c)
+	if !ok {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || strings.EqualFold(req.Name, "general") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的频道名称",
+		})
+		return
+	}
+
+	var count int64
+	repository.DB.Model(&model.GroupChannel{}).Where("group_id = ?", groupID).Count(&count)
+	if count >= maxGroupChannels {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "频道数量已达上限",
+		})
+		return
+	}
+
+	var existing model.GroupChannel
+	if repository.DB.Where("group_id = ? AND name = ?", groupID, req.Name).First(&existing).Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "频道名称已存在",
+		})
+		return
+	}
+
+	channel := model.GroupChannel{
+		GroupID:      groupID,
+		Name:         req.Name,
+		Description:  req.Description,
+		IsRestricted: req.IsRestricted,
+		SortOrder:    req.SortOrder,
+		CreatedBy:    groupMember.UserID,
+	}
+
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&channel).Error; err != nil {
+			return err
+		}
+		if channel.IsRestricted {
+			if err := replaceGroupChannelMembers(tx, &channel, append(req.MemberIDs, groupMember.UserID)); err != nil {
+				return err
+			}
+		}
+		recordGroupAudit(tx, groupID, groupMember.UserID, AuditCreateChannel, channel.ID, nil, channelAuditValue(&channel), "")
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "创建频道失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "频道创建成功",
+		"channel": channel,
+	})
+}
+
+// UpdateGroupChannel 修改群聊频道
+func UpdateGroupChannel(c *gin.Context) {
+	var req struct {
+		Name         string  `json:"name" binding:"max=50"`
+		Description  *string `json:"description" binding:"omitempty,max=255"`
+		IsRestricted *bool   `json:"is_restricted"`
+		SortOrder    *int    `json:"sort_order"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	groupID, groupMember, ok := getGroupChannelManager(c)
+	if !ok {
+		return
+	}
+
+	channelID, err := strconv.ParseUint(c.Param("channel_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的频道ID",
+		})
+		return
+	}
+
+	channel := findGroupChannel(groupID, channelID)
+	if channel == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "频道不存在",
+		})
+		return
+	}
+
+	before := channelAuditValue(channel)
+	if name := strings.TrimSpace(req.Name); name != "" && name != channel.Name {
+		if strings.EqualFold(name, "general") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的频道名称",
+			})
+			return
+		}
+		var existing model.GroupChannel
+		if repository.DB.Where("group_id = ? AND name = ?", groupID, name).First(&existing).Error == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "频道名称已存在",
+			})
+			return
+		}
+		channel.Name = name
+	}
+	if req.Description != nil {
+		channel.Description = *req.Description
+	}
+	if req.IsRestricted != nil {
+		channel.IsRestricted = *req.IsRestricted
+	}
+	if req.SortOrder != nil {
+		channel.SortOrder = *req.SortOrder
+	}
+
+	if err := repository.DB.Save(channel).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "修改频道失败",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, groupID, groupMember.UserID, AuditUpdateChannel, channel.ID, before, channelAuditValue(channel), "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "频道修改成功",
+		"channel": channel,
+	})
+}
+
+// DeleteGroupChannel 删除群聊频道及其消息
+func DeleteGroupChannel(c *gin.Context) {
+	groupID, groupMember, ok := getGroupChannelManager(c)
+	if !ok {
+		return
+	}
+
+	channelID, err := strconv.ParseUint(c.Param("channel_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的频道ID",
+		})
+		return
+	}
+
+	channel := findGroupChannel(groupID, channelID)
+	if channel == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "频道不存在",
+		})
+		return
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("channel_id = ?", channel.ID).Delete(&model.GroupChannelMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ? AND channel_id = ?", groupID, channel.ID).Delete(&model.GroupChannelRead{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("receiver_type = 'group' AND receiver_id = ? AND channel_id = ?", groupID, channel.ID).Delete(&model.Message{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(channel).Error; err != nil {
+			return err
+		}
+		recordGroupAudit(tx, groupID, groupMember.UserID, AuditDeleteChannel, channel.ID, channelAuditValue(channel), nil, "")
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "删除频道失败",
+		})
+		return
+	}
+
+	notifyGroupMembers(&Manager, groupID, WebSocketMessage{
+		Type: "group_channel_deleted",
+		Payload: gin.H{
+			"group_id":   groupID,
+			"channel_id": channel.ID,
+		},
+		Timestamp: time.Now(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "频道已删除",
+	})
+}
+
+// replaceGroupChannelMembers 在事务中替换受限频道的成员，只保留仍在群内的用户
+func replaceGroupChannelMembers(tx *gorm.DB, channel *model.GroupChannel, userIDs []uint64) error {
+	if err := tx.Where("channel_id = ?", channel.ID).Delete(&model.GroupChannelMember{}).Error; err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	var memberIDs []uint64
+	if err := tx.Model(&model.GroupMember{}).Where("group_id = ? AND user_id IN ?", channel.GroupID, userIDs).
+		Pluck("user_id", &memberIDs).Error; err != nil {
+		return err
+	}
+	if len(memberIDs) == 0 {
+		return nil
+	}
+
+	members := make([]model.GroupChannelMember, len(memberIDs))
+	for i, userID := range memberIDs {
+		members[i] = model.GroupChannelMember{
+			ChannelID: channel.ID,
+			GroupID:   channel.GroupID,
+			UserID:    userID,
+		}
+	}
+	return tx.Create(&members).Error
+}
+
+// SetGroupChannelMembers 设置受限频道的成员列表
+func SetGroupChannelMembers(c *gin.Context) {
+	var req struct {
+		UserIDs []uint64 `json:"user_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	groupID, groupMember, ok := getGroupChannelManager(c)
+	if !ok {
+		return
+	}
+
+	channelID, err := strconv.ParseUint(c.Param("channel_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的频道ID",
+		})
+		return
+	}
+
+	channel := findGroupChannel(groupID, channelID)
+	if channel == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "频道不存在",
+		})
+		return
+	}
+
+	if !channel.IsRestricted {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "只有受限频道可以设置成员",
+		})
+		return
+	}
+
+	var before []uint64
+	repository.DB.Model(&model.GroupChannelMember{}).Where("channel_id = ?", channel.ID).Pluck("user_id", &before)
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceGroupChannelMembers(tx, channel, req.UserIDs)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "设置频道成员失败",
+		})
+		return
+	}
+
+	var memberIDs []uint64
+	repository.DB.Model(&model.GroupChannelMember{}).Where("channel_id = ?", channel.ID).Pluck("user_id", &memberIDs)
+
+	recordGroupAudit(repository.DB, groupID, groupMember.UserID, AuditSetChannelMembers, channel.ID,
+		gin.H{"member_ids": before}, gin.H{"member_ids": memberIDs}, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "频道成员设置成功",
+		"member_ids": memberIDs,
+	})
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DissolveGroup 解散群聊，仅群主可操作
+// 解散后删除群成员、入群申请、邀请、置顶等群聊数据；archive_messages 为 true 时保留聊天记录存档，否则一并删除
+// 群聊拥有的表情包连同表情文件一并删除，存档中引用这些表情的消息不再关联表情
+func DissolveGroup(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		ArchiveMessages bool `json:"archive_messages"`
+	}
+
+	// 请求体可选
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	// 检查用户是否是群主
+	var groupMember model.GroupMember
+	result := repository.DB.Where("group_id = ? AND user_id = ? AND role = ?",
+		groupID, currentUserID.(uint64), "owner").First(&groupMember)
+	if result.Error != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "只有群主可以解散群聊",
+		})
+		return
+	}
+
+	// 解散前记录成员，用于通知
+	var memberIDs []uint64
+	repository.DB.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Pluck("user_id", &memberIDs)
+
+	// 群聊拥有的表情，事务提交后删除文件
+	var stickers []model.Sticker
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		for _, value := range []interface{}{
+			&model.GroupMember{},
+			&model.GroupRequest{},
+			&model.GroupInvitation{},
+			&model.GroupInvite{},
+			&model.GroupInviteUse{},
+			&model.GroupBan{},
+			&model.GroupRole{},
+			&model.GroupQuestion{},
+			&model.GroupChannel{},
+			&model.GroupChannelMember{},
+			&model.GroupChannelRead{},
+			&model.GroupAnnouncement{},
+			&model.GroupAnnouncementAck{},
+			&model.GroupStickerPack{},
+		} {
+			if err := tx.Where("group_id = ?", groupID).Delete(value).Error; err != nil {
+				return err
+			}
+		}
+
+		// 删除群聊拥有的表情包及其表情，以及其他群聊对这些表情包的启用记录
+		var packIDs []uint64
+		if err := tx.Model(&model.StickerPack{}).Where("owner_type = ? AND owner_id = ?", "group", groupID).Pluck("id", &packIDs).Error; err != nil {
+			return err
+		}
+		if len(packIDs) > 0 {
+			if err := tx.Select("id, file_url").Where("pack_id IN ?", packIDs).Find(&stickers).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("pack_id IN ?", packIDs).Delete(&model.GroupStickerPack{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("pack_id IN ?", packIDs).Delete(&model.Sticker{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", packIDs).Delete(&model.StickerPack{}).Error; err != nil {
+				return err
+			}
+		}
+
+		// 删除成员对该群聊的置顶、免打扰等会话设置和草稿
+		if err := tx.Where("conversation_type = ? AND conversation_id = ?", "group", groupID).Delete(&model.ConversationSetting{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("conversation_type = ? AND conversation_id = ?", "group", groupID).Delete(&model.Draft{}).Error; err != nil {
+			return err
+		}
+
+		if !req.ArchiveMessages {
+			if err := tx.Where("receiver_type = ? AND receiver_id = ?", "group", groupID).Delete(&model.Message{}).Error; err != nil {
+				return err
+			}
+		} else if len(stickers) > 0 {
+			// 存档的表情消息引用的表情已被删除，清除表情关联
+			stickerIDs := make([]uint64, 0, len(stickers))
+			for _, sticker := range stickers {
+				stickerIDs = append(stickerIDs, sticker.ID)
+			}
+			if err := tx.Model(&model.Message{}).Where("receiver_type = ? AND receiver_id = ? AND sticker_id IN ?", "group", groupID, stickerIDs).
+				Update("sticker_id", 0).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&group).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "解散群聊失败",
+		})
+		return
+	}
+
+	for _, sticker := range stickers {
+		utils.RemoveUploadFile(sticker.FileURL)
+	}
+
+	// 审计日志在群聊解散后保留
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditDissolve, groupID,
+		gin.H{"name": group.Name, "member_count": len(memberIDs)}, gin.H{"archive_messages": req.ArchiveMessages}, "")
+
+	Manager.RemoveGroup(groupID)
+	if len(memberIDs) > 0 {
+		Manager.SendToUsers(WebSocketMessage{
+			Type: "group_dissolved",
+			Payload: gin.H{
+				"group_id":   groupID,
+				"group_name": group.Name,
+			},
+			Timestamp: time.Now(),
+		}, memberIDs...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "群聊已解散",
+	})
+}
+
+// transferOwnershipOnLeave 群主退出时将群主身份转让给任职最久的管理员
+// 没有管理员时返回 gorm.ErrRecordNotFound
+func transferOwnershipOnLeave(tx *gorm.DB, owner *model.GroupMember) (*model.GroupMember, error) {
+	var newOwner model.GroupMember
+	err := tx.Where("group_id = ? AND role = ? AND user_id <> ?", owner.GroupID, "admin", owner.UserID).
+		Order("joined_at ASC, id ASC").First(&newOwner).Error
+	if err != nil {
+		return nil, err
+	}
+
+	newOwner.Role = "owner"
+	newOwner.RoleID = 0
+	if err := tx.Model(&newOwner).Select("role", "role_id").Updates(&newOwner).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&model.Group{}).Where("id = ?", owner.GroupID).Update("owner_id", newOwner.UserID).Error; err != nil {
+		return nil, err
+	}
+	return &newOwner, nil
+}
@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 单次最多邀请的用户数量
+const maxGroupInvitees = 50
+
+// InviteGroupMembers 邀请好友加入群聊
+func InviteGroupMembers(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		UserIDs []uint64 `json:"user_ids" binding:"required,min=1"`
+		Message string   `json:"message" binding:"max=500"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.UserIDs) > maxGroupInvitees {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	var groupMember model.GroupMember
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).First(&groupMember)
+	if result.Error != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您不是该群聊的成员",
+		})
+		return
+	}
+
+	if !hasGroupPermission(&group, &groupMember, PermInvite) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "该群聊仅允许群主和管理员邀请成员",
+		})
+		return
+	}
+
+	// 只能邀请自己的好友
+	var friendIDs []uint64
+	repository.DB.Model(&model.Friend{}).
+		Where("user_id = ? AND friend_id IN ? AND status = 'active'", currentUserID.(uint64), req.UserIDs).
+		Pluck("friend_id", &friendIDs)
+
+	// 跳过已在群内、已有待处理邀请或被加入黑名单的用户
+	var memberIDs, pendingIDs []uint64
+	if len(friendIDs) > 0 {
+		repository.DB.Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id IN ?", groupID, friendIDs).Pluck("user_id", &memberIDs)
+		repository.DB.Model(&model.GroupInvitation{}).
+			Where("group_id = ? AND invitee_id IN ? AND status = 'pending'", groupID, friendIDs).Pluck("invitee_id", &pendingIDs)
+	}
+	skip := bannedGroupUserIDs(groupID, friendIDs)
+	for _, id := range memberIDs {
+		skip[id] = true
+	}
+	for _, id := range pendingIDs {
+		skip[id] = true
+	}
+
+	var invitations []model.GroupInvitation
+	for _, friendID := range friendIDs {
+		if skip[friendID] {
+			continue
+		}
+		skip[friendID] = true
+		invitations = append(invitations, model.GroupInvitation{
+			GroupID:   groupID,
+			InviterID: currentUserID.(uint64),
+			InviteeID: friendID,
+			Message:   req.Message,
+			Status:    "pending",
+		})
+	}
+
+	if len(invitations) > 0 {
+		if err := repository.DB.Create(&invitations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "发送群聊邀请失败",
+			})
+			return
+		}
+
+		for _, invitation := range invitations {
+			Manager.SendToUsers(WebSocketMessage{
+				Type: "group_invitation",
+				Payload: gin.H{
+					"invitation_id": invitation.ID,
+					"group_id":      group.ID,
+					"group_name":    group.Name,
+					"inviter_id":    invitation.InviterID,
+					"message":       invitation.Message,
+				},
+				Timestamp: time.Now(),
+			}, invitation.InviteeID)
+		}
+	}
+
+	invitedIDs := make([]uint64, len(invitations))
+	for i, invitation := range invitations {
+		invitedIDs[i] = invitation.InviteeID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "群聊邀请已发送",
+		"invited_ids": invitedIDs,
+	})
+}
+
+// GetGroupInvitations 获取收到的群聊邀请
+func GetGroupInvitations(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var invitations []model.GroupInvitation
+	err := repository.DB.Where("invitee_id = ? AND status = 'pending'", currentUserID.(uint64)).
+		Order("created_at DESC").Find(&invitations).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取群聊邀请失败",
+		})
+		return
+	}
+
+	// 添加群聊和邀请人信息
+	invitationData := make([]gin.H, 0, len(invitations))
+	for _, invitation := range invitations {
+		var group model.Group
+		if repository.DB.Select("id, name, avatar_url").Where("id = ?", invitation.GroupID).First(&group).Error != nil {
+			continue
+		}
+		var inviter model.User
+		repository.DB.Select("id, username, nickname, avatar_url").Where("id = ?", invitation.InviterID).First(&inviter)
+
+		invitationData = append(invitationData, gin.H{
+			"id":               invitation.ID,
+			"group_id":         group.ID,
+			"group_name":       group.Name,
+			"group_avatar_url": group.AvatarURL,
+			"inviter_id":       inviter.ID,
+			"inviter_username": inviter.Username,
+			"inviter_nickname": inviter.Nickname,
+			"message":          invitation.Message,
+			"created_at":       invitation.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": invitationData,
+	})
+}
+
+// HandleGroupInvitation 接受或拒绝群聊邀请
+// 群聊需要审批且邀请人没有管理群成员的权限时，接受邀请会进入群聊申请列表等待审批
+func HandleGroupInvitation(c *gin.Context) {
+	invitationIDStr := c.Param("invitation_id")
+	invitationID, err := strconv.ParseUint(invitationIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的邀请ID",
+		})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=accept decline"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var invitation model.GroupInvitation
+	result := repository.DB.Where("id = ? AND invitee_id = ?", invitationID, currentUserID.(uint64)).First(&invitation)
+	if result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "邀请不存在",
+		})
+		return
+	}
+
+	if invitation.Status != "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "该邀请已处理",
+		})
+		return
+	}
+
+	now := time.Now()
+	invitation.HandledAt = &now
+
+	if req.Action == "decline" {
+		invitation.Status = "declined"
+		if err := repository.DB.Save(&invitation).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "处理邀请失败",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "已拒绝群聊邀请",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", invitation.GroupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	var existingMember model.GroupMember
+	if repository.DB.Where("group_id = ? AND user_id = ?", group.ID, currentUserID.(uint64)).First(&existingMember).Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "您已经是该群聊的成员",
+		})
+		return
+	}
+
+	if findGroupBan(group.ID, currentUserID.(uint64)) != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您已被禁止加入该群聊",
+		})
+		return
+	}
+
+	// 邀请人已不在群内或已失去邀请权限时，邀请失效
+	var inviter model.GroupMember
+	if repository.DB.Where("group_id = ? AND user_id = ?", group.ID, invitation.InviterID).First(&inviter).Error != nil ||
+		!hasGroupPermission(&group, &inviter, PermInvite) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "邀请已失效",
+		})
+		return
+	}
+
+	needApproval := group.NeedApproval && !hasGroupPermission(&group, &inviter, PermManageMembers)
+	invitation.Status = "accepted"
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&invitation).Error; err != nil {
+			return err
+		}
+
+		if needApproval {
+			var existingRequest model.GroupRequest
+			if tx.Where("user_id = ? AND group_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)",
+				currentUserID.(uint64), group.ID, "pending", time.Now()).First(&existingRequest).Error == nil {
+				return nil
+			}
+			return tx.Create(&model.GroupRequest{
+				UserID:    currentUserID.(uint64),
+				GroupID:   group.ID,
+				Message:   "通过成员邀请申请加入群聊",
+				InviterID: invitation.InviterID,
+				Status:    "pending",
+				ExpiresAt: groupRequestExpiresAt(&group),
+			}).Error
+		}
+
+		if err := checkGroupCapacity(tx, group.ID); err != nil {
+			return err
+		}
+		return tx.Create(&model.GroupMember{
+			GroupID:  group.ID,
+			UserID:   currentUserID.(uint64),
+			Role:     "member",
+			Unread:   0,
+			IsMute:   false,
+			JoinedAt: time.Now(),
+		}).Error
+	})
+	if errors.Is(err, errGroupFull) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "处理邀请失败",
+		})
+		return
+	}
+
+	if needApproval {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "已接受邀请，请等待管理员审批",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "成功加入群聊",
+	})
+}
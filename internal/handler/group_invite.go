@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errGroupInviteUnavailable 邀请链接已失效（被撤销、过期或次数用尽）
+var errGroupInviteUnavailable = errors.New("邀请链接无效或已过期")
+
+// generateInviteCode 生成邀请码
+func generateInviteCode() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// isGroupInviteValid 判断邀请链接当前是否可用
+func isGroupInviteValid(invite *model.GroupInvite) bool {
+	if invite.IsRevoked {
+		return false
+	}
+	if invite.ExpiresAt != nil && !invite.ExpiresAt.After(time.Now()) {
+		return false
+	}
+	return invite.MaxUses == 0 || invite.UseCount < invite.MaxUses
+}
+
+// findValidGroupInvite 根据邀请码查找群聊的可用邀请链接
+func findValidGroupInvite(groupID uint64, code string) *model.GroupInvite {
+	var invite model.GroupInvite
+	if err := repository.DB.Where("group_id = ? AND code = ?", groupID, code).First(&invite).Error; err != nil {
+		return nil
+	}
+	if !isGroupInviteValid(&invite) {
+		return nil
+	}
+	return &invite
+}
+
+// redeemGroupInvite 在事务中占用一次邀请链接的使用次数，并记录使用者
+func redeemGroupInvite(tx *gorm.DB, invite *model.GroupInvite, userID uint64, result string) error {
+	update := tx.Model(&model.GroupInvite{}).
+		Where("id = ? AND is_revoked = ? AND (expires_at IS NULL OR expires_at > ?) AND (max_uses = 0 OR use_count < max_uses)",
+			invite.ID, false, time.Now()).
+		Update("use_count", gorm.Expr("use_count + 1"))
+	if update.Error != nil {
+		return update.Error
+	}
+	if update.RowsAffected == 0 {
+		return errGroupInviteUnavailable
+	}
+
+	return tx.Create(&model.GroupInviteUse{
+		InviteID: invite.ID,
+		GroupID:  invite.GroupID,
+		UserID:   userID,
+		Result:   result,
+	}).Error
+}
+
+// getGroupInviteManager 检查当前用户是否有管理邀请链接的权限，返回群聊ID和用户ID
+func getGroupInviteManager(c *gin.Context) (uint64, uint64, bool) {
+	groupID, err := strconv.ParseUint(c.Param("group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return 0, 0, false
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return 0, 0, false
+	}
+
+	if _, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理邀请链接",
+		})
+		return 0, 0, false
+	}
+
+	return groupID, currentUserID.(uint64), true
+}
+
+// CreateGroupInvite 创建群聊邀请链接
+func CreateGroupInvite(c *gin.Context) {
+	var req struct {
+		ExpiresIn       int64 `json:"expires_in"` // 有效期（秒），为 0 表示永不过期
+		MaxUses         int   `json:"max_uses"`   // 最大使用次数，为 0 表示不限
+		RequireApproval *bool `json:"require_approval"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.ExpiresIn < 0 || req.MaxUses < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	groupID, userID, ok := getGroupInviteManager(c)
+	if !ok {
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "生成邀请码失败",
+		})
+		return
+	}
+
+	// 未指定时沿用群聊的审批设置
+	requireApproval := group.NeedApproval
+	if req.RequireApproval != nil {
+		requireApproval = *req.RequireApproval
+	}
+
+	invite := model.GroupInvite{
+		GroupID:         groupID,
+		Code:            code,
+		CreatedBy:       userID,
+		MaxUses:         req.MaxUses,
+		RequireApproval: requireApproval,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if err := repository.DB.Create(&invite).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "创建邀请链接失败",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, groupID, userID, AuditCreateInvite, invite.ID, nil, gin.H{
+		"expires_at":       invite.ExpiresAt,
+		"max_uses":         invite.MaxUses,
+		"require_approval": invite.RequireApproval,
+	}, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "邀请链接创建成功",
+		"invite":  invite,
+	})
+}
+
+// GetGroupInvites 获取群聊邀请链接列表
+func GetGroupInvites(c *gin.Context) {
+	groupID, _, ok := getGroupInviteManager(c)
+	if !ok {
+		return
+	}
+
+	var invites []model.GroupInvite
+	if err := repository.DB.Where("group_id = ?", groupID).Order("created_at DESC").Find(&invites).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取邀请链接列表失败",
+		})
+		return
+	}
+
+	inviteData := make([]gin.H, len(invites))
+	for i := range invites {
+		inviteData[i] = gin.H{
+			"invite": invites[i],
+			"valid":  isGroupInviteValid(&invites[i]),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invites": inviteData,
+	})
+}
+
+// GetGroupInviteUses 获取邀请链接的使用记录
+func GetGroupInviteUses(c *gin.Context) {
+	groupID, _, ok := getGroupInviteManager(c)
+	if !ok {
+		return
+	}
+
+	inviteID, err := strconv.ParseUint(c.Param("invite_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的邀请链接ID",
+		})
+		return
+	}
+
+	var uses []model.GroupInviteUse
+	err = repository.DB.Where("invite_id = ? AND group_id = ?", inviteID, groupID).Order("created_at DESC").Find(&uses).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取邀请链接使用记录失败",
+		})
+		return
+	}
+
+	// 添加使用者信息
+	useData := make([]gin.H, 0, len(uses))
+	for _, use := range uses {
+		var user model.User
+		if repository.DB.Select("id, username, nickname, avatar_url").Where("id = ?", use.UserID).First(&user).Error != nil {
+			continue
+		}
+		useData = append(useData, gin.H{
+			"user_id":    user.ID,
+			"username":   user.Username,
+			"nickname":   user.Nickname,
+			"avatar_url": user.AvatarURL,
+			"result":     use.Result,
+			"created_at": use.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"uses": useData,
+	})
+}
+
+// RevokeGroupInvite 撤销群聊邀请链接
+func RevokeGroupInvite(c *gin.Context) {
+	groupID, userID, ok := getGroupInviteManager(c)
+	if !ok {
+		return
+	}
+
+	inviteID, err := strconv.ParseUint(c.Param("invite_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的邀请链接ID",
+		})
+		return
+	}
+
+	var invite model.GroupInvite
+	if err := repository.DB.Where("id = ? AND group_id = ?", inviteID, groupID).First(&invite).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "邀请链接不存在",
+		})
+		return
+	}
+
+	if invite.IsRevoked {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "邀请链接已被撤销",
+		})
+		return
+	}
+
+	now := time.Now()
+	invite.IsRevoked = true
+	invite.RevokedBy = userID
+	invite.RevokedAt = &now
+	if err := repository.DB.Save(&invite).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "撤销邀请链接失败",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, groupID, userID, AuditRevokeInvite, invite.ID,
+		gin.H{"is_revoked": false}, gin.H{"is_revoked": true}, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "邀请链接已撤销",
+	})
+}
+
+// GetGroupInviteInfo 根据邀请码查看群聊信息，用于加入前预览
+func GetGroupInviteInfo(c *gin.Context) {
+	var invite model.GroupInvite
+	if err := repository.DB.Where("code = ?", c.Param("code")).First(&invite).Error; err != nil || !isGroupInviteValid(&invite) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errGroupInviteUnavailable.Error(),
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", invite.GroupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	var memberCount int64
+	repository.DB.Model(&model.GroupMember{}).Where("group_id = ?", group.ID).Count(&memberCount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"group": gin.H{
+			"id":           group.ID,
+			"name":         group.Name,
+			"avatar_url":   group.AvatarURL,
+			"description":  group.Description,
+			"member_count": memberCount,
+			"max_members":  groupMemberLimit(&group),
+		},
+		"require_approval": invite.RequireApproval,
+		"expires_at":       invite.ExpiresAt,
+	})
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 检查禁言是否到期的时间间隔
+const groupMuteCheckInterval = 30 * time.Second
+
+// GroupMutePayload 禁言状态变更通知
+// UserID 为 0 表示全群禁言
+type GroupMutePayload struct {
+	GroupID   uint64     `json:"group_id"`
+	UserID    uint64     `json:"user_id,omitempty"`
+	IsMute    bool       `json:"is_mute"`
+	Permanent bool       `json:"permanent"`
+	MuteUntil *time.Time `json:"mute_until,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Expired   bool       `json:"expired,omitempty"` // 是否为到期自动解除
+}
+
+// isMuteActive 判断禁言当前是否生效
+// 未设置到期时间的禁言视为永久禁言
+func isMuteActive(isMute, permanent bool, expires *time.Time) bool {
+	if !isMute {
+		return false
+	}
+	return permanent || expires == nil || expires.After(time.Now())
+}
+
+// muteUntil 根据禁言时长计算到期时间，时长为 0 表示永久禁言
+func muteUntil(duration int64) (*time.Time, bool) {
+	if duration <= 0 {
+		return nil, true
+	}
+	expires := time.Now().Add(time.Duration(duration) * time.Second)
+	return &expires, false
+}
+
+// checkGroupMute 检查群成员当前能否在群内发言，被禁言时返回错误帧内容
+// 群主和管理员不受全群禁言限制，但仍受个人禁言限制
+func checkGroupMute(member *model.GroupMember) *ErrorPayload {
+	if isMuteActive(member.IsMute, member.MutePermanent, member.MuteTexpires) {
+		payload := &ErrorPayload{
+			Code:    "member_muted",
+			Message: "您已被禁言",
+			GroupID: member.GroupID,
+		}
+		if !member.MutePermanent {
+			payload.MuteUntil = member.MuteTexpires
+		}
+		return payload
+	}
+
+	if member.Role == "owner" || member.Role == "admin" {
+		return nil
+	}
+
+	var group model.Group
+	if err := repository.DB.Select("id, is_mute, mute_permanent, mute_texpires").Where("id = ?", member.GroupID).First(&group).Error; err != nil {
+		return nil
+	}
+	if isMuteActive(group.IsMute, group.MutePermanent, group.MuteTexpires) {
+		payload := &ErrorPayload{
+			Code:    "group_muted",
+			Message: "群聊已开启全员禁言",
+			GroupID: member.GroupID,
+		}
+		if !group.MutePermanent {
+			payload.MuteUntil = group.MuteTexpires
+		}
+		return payload
+	}
+	return nil
+}
+
+// notifyGroupMembers 向群聊的所有成员推送消息
+func notifyGroupMembers(manager *ClientManager, groupID uint64, message WebSocketMessage) {
+	var memberIDs []uint64
+	repository.DB.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Pluck("user_id", &memberIDs)
+	if len(memberIDs) > 0 {
+		manager.SendToUsers(message, memberIDs...)
+	}
+}
+
+// notifyGroupMute 向群成员推送禁言状态变更
+func notifyGroupMute(manager *ClientManager, payload GroupMutePayload) {
+	notifyGroupMembers(manager, payload.GroupID, WebSocketMessage{
+		Type:      "group_mute_updated",
+		Payload:   payload,
+		Timestamp: time.Now(),
+	})
+}
+
+// SetGroupAllMute 设置全群禁言
+func SetGroupAllMute(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		Mute     *bool  `json:"mute" binding:"required"`
+		Reason   string `json:"reason"`
+		Duration int64  `json:"duration"` // 禁言时长（秒），为 0 表示永久禁言
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.Duration < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有禁言权限
+	_, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermMute)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限设置全群禁言",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	// 设置禁言
+	before := muteAuditValue(group.IsMute, group.MutePermanent, group.MuteTexpires)
+	group.IsMute = *req.Mute
+	if *req.Mute {
+		now := time.Now()
+		group.MuteAt = &now
+		group.MuteReason = req.Reason
+		group.MuteBy = currentUserID.(uint64)
+		group.MuteTexpires, group.MutePermanent = muteUntil(req.Duration)
+	} else {
+		group.MuteAt = nil
+		group.MuteReason = ""
+		group.MuteBy = 0
+		group.MuteTexpires = nil
+		group.MutePermanent = false
+	}
+
+	result := repository.DB.Model(&group).Select("is_mute", "mute_at", "mute_reason", "mute_by", "mute_texpires", "mute_permanent").Updates(&group)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "设置全群禁言失败",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, group.ID, currentUserID.(uint64), AuditMuteAll, group.ID, before,
+		muteAuditValue(group.IsMute, group.MutePermanent, group.MuteTexpires), req.Reason)
+
+	notifyGroupMute(&Manager, GroupMutePayload{
+		GroupID:   group.ID,
+		IsMute:    group.IsMute,
+		Permanent: group.MutePermanent,
+		MuteUntil: group.MuteTexpires,
+		Reason:    group.MuteReason,
+	})
+
+	actionText := "解除"
+	if *req.Mute {
+		actionText = "开启"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        actionText + "全群禁言成功",
+		"is_mute":        group.IsMute,
+		"mute_permanent": group.MutePermanent,
+		"mute_texpires":  group.MuteTexpires,
+	})
+}
+
+// StartGroupMuteWorker 定时解除到期的群聊禁言和成员禁言
+func StartGroupMuteWorker() {
+	ticker := time.NewTicker(groupMuteCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		liftExpiredGroupMutes()
+	}
+}
+
+// liftExpiredGroupMutes 解除到期的禁言，并通知群成员
+func liftExpiredGroupMutes() {
+	now := time.Now()
+
+	var groups []model.Group
+	repository.DB.Select("id").
+		Where("is_mute = ? AND mute_permanent = ? AND mute_texpires IS NOT NULL AND mute_texpires <= ?", true, false, now).
+		Find(&groups)
+	for _, group := range groups {
+		// 条件更新，避免多个进程重复解除和通知
+		result := repository.DB.Model(&model.Group{}).
+			Where("id = ? AND is_mute = ? AND mute_permanent = ? AND mute_texpires <= ?", group.ID, true, false, now).
+			Updates(map[string]interface{}{
+				"is_mute":       false,
+				"mute_at":       nil,
+				"mute_reason":   "",
+				"mute_by":       0,
+				"mute_texpires": nil,
+			})
+		if result.Error != nil {
+			utils.Errorf("解除全群禁言失败: %v", result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			notifyGroupMute(&Manager, GroupMutePayload{GroupID: group.ID, Expired: true})
+		}
+	}
+
+	var members []model.GroupMember
+	repository.DB.Select("id, group_id, user_id").
+		Where("is_mute = ? AND mute_permanent = ? AND mute_texpires IS NOT NULL AND mute_texpires <= ?", true, false, now).
+		Find(&members)
+	for _, member := range members {
+		result := repository.DB.Model(&model.GroupMember{}).
+			Where("id = ? AND is_mute = ? AND mute_permanent = ? AND mute_texpires <= ?", member.ID, true, false, now).
+			Updates(map[string]interface{}{
+				"is_mute":       false,
+				"mute_at":       nil,
+				"mute_reason":   "",
+				"mute_by":       0,
+				"mute_texpires": nil,
+			})
+		if result.Error != nil {
+			utils.Errorf("解除群成员禁言失败: %v", result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			notifyGroupMute(&Manager, GroupMutePayload{GroupID: member.GroupID, UserID: member.UserID, Expired: true})
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 每个群聊最多设置的入群问题数量
+const maxGroupQuestions = 10
+
+// 检查入群申请是否过期的时间间隔
+const groupRequestExpireInterval = time.Minute
+
+// GroupJoinAnswer 申请人对入群问题的回答
+// Correct 为空表示该问题为自由作答，需要管理员人工判断
+type GroupJoinAnswer struct {
+	QuestionID uint64 `json:"question_id"`
+	Question   string `json:"question"`
+	Answer     string `json:"answer"`
+	Correct    *bool  `json:"correct,omitempty"`
+}
+
+// getGroupQuestions 获取群聊的入群问题
+func getGroupQuestions(groupID uint64) []model.GroupQuestion {
+	var questions []model.GroupQuestion
+	repository.DB.Where("group_id = ?", groupID).Order("sort_order ASC, id ASC").Find(&questions)
+	return questions
+}
+
+// gradeGroupAnswers 根据入群问题检查申请人的回答
+// 有未回答的问题时返回 false；allCorrect 仅在所有问题都设置了预期答案且全部答对时为 true
+func gradeGroupAnswers(questions []model.GroupQuestion, answers map[uint64]string) (result []GroupJoinAnswer, allCorrect bool, ok bool) {
+	allCorrect = true
+	for _, question := range questions {
+		answer := strings.TrimSpace(answers[question.ID])
+		if answer == "" {
+			return nil, false, false
+		}
+
+		item := GroupJoinAnswer{
+			QuestionID: question.ID,
+			Question:   question.Question,
+			Answer:     answer,
+		}
+		if question.Answer == "" {
+			allCorrect = false
+		} else {
+			correct := strings.EqualFold(answer, strings.TrimSpace(question.Answer))
+			item.Correct = &correct
+			allCorrect = allCorrect && correct
+		}
+		result = append(result, item)
+	}
+	return result, allCorrect, true
+}
+
+// decodeGroupAnswers 解析入群申请中的问题回答
+func decodeGroupAnswers(request *model.GroupRequest) []GroupJoinAnswer {
+	answers := []GroupJoinAnswer{}
+	if request.Answers != "" {
+		json.Unmarshal([]byte(request.Answers), &answers)
+	}
+	return answers
+}
+
+// isGroupRequestPending 判断入群申请是否仍待处理且未过期
+func isGroupRequestPending(request *model.GroupRequest) bool {
+	return request.Status == "pending" && (request.ExpiresAt == nil || request.ExpiresAt.After(time.Now()))
+}
+
+// groupRequestExpiresAt 根据群聊设置计算新入群申请的过期时间
+func groupRequestExpiresAt(group *model.Group) *time.Time {
+	if group.RequestTTL <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(time.Duration(group.RequestTTL) * time.Second)
+	return &expiresAt
+}
+
+// GetGroupQuestions 获取群聊的入群问题
+// 有管理成员权限的用户可以看到预期答案
+func GetGroupQuestions(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	_, canManage := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers)
+
+	questions := getGroupQuestions(groupID)
+	questionData := make([]gin.H, len(questions))
+	for i, question := range questions {
+		questionData[i] = gin.H{
+			"id":         question.ID,
+			"question":   question.Question,
+			"free_form":  question.Answer == "",
+			"sort_order": question.SortOrder,
+		}
+		if canManage {
+			questionData[i]["answer"] = question.Answer
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"questions":    questionData,
+		"auto_approve": group.AutoApprove,
+		"request_ttl":  group.RequestTTL,
+	})
+}
+
+// SetGroupQuestions 设置群聊的入群问题及申请审核规则，会覆盖原有的问题列表
+func SetGroupQuestions(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		Questions []struct {
+			Question string `json:"question" binding:"required,max=255"`
+			Answer   string `json:"answer" binding:"max=255"` // 为空表示自由作答
+		} `json:"questions" binding:"dive"`
+		AutoApprove *bool  `json:"auto_approve"`
+		RequestTTL  *int64 `json:"request_ttl"` // 入群申请的有效期（秒），为 0 表示不过期
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || (req.RequestTTL != nil && *req.RequestTTL < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	if len(req.Questions) > maxGroupQuestions {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "入群问题数量已达上限",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有管理群成员的权限
+	if _, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限设置入群问题",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	questions := make([]model.GroupQuestion, 0, len(req.Questions))
+	for i, q := range req.Questions {
+		questions = append(questions, model.GroupQuestion{
+			GroupID:   groupID,
+			Question:  strings.TrimSpace(q.Question),
+			Answer:    strings.TrimSpace(q.Answer),
+			SortOrder: i,
+		})
+	}
+
+	before := questionsAuditValue(&group, getGroupQuestions(groupID))
+	if req.AutoApprove != nil {
+		group.AutoApprove = *req.AutoApprove
+	}
+	if req.RequestTTL != nil {
+		group.RequestTTL = *req.RequestTTL
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&group).Select("auto_approve", "request_ttl").Updates(&group).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", groupID).Delete(&model.GroupQuestion{}).Error; err != nil {
+			return err
+		}
+		if len(questions) > 0 {
+			if err := tx.Create(&questions).Error; err != nil {
+				return err
+			}
+		}
+		recordGroupAudit(tx, groupID, currentUserID.(uint64), AuditSetQuestions, groupID, before, questionsAuditValue(&group, questions), "")
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "设置入群问题失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "入群问题设置成功",
+		"questions":    questions,
+		"auto_approve": group.AutoApprove,
+		"request_ttl":  group.RequestTTL,
+	})
+}
+
+// StartGroupRequestExpiryWorker 定时将过期的入群申请标记为已过期
+func StartGroupRequestExpiryWorker() {
+	ticker := time.NewTicker(groupRequestExpireInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result := repository.DB.Model(&model.GroupRequest{}).
+			Where("status = ? AND expires_at IS NOT NULL AND expires_at <= ?", "pending", time.Now()).
+			Update("status", "expired")
+		if result.Error != nil {
+			utils.Errorf("标记过期入群申请失败: %v", result.Error)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 批量处理入群申请时单次最多处理的数量
+const maxBatchGroupRequests = 200
+
+var (
+	// errGroupRequestClosed 申请已被处理或已过期
+	errGroupRequestClosed = errors.New("该申请已处理或已过期")
+	// errGroupRequestBanned 申请人已被加入群聊黑名单
+	errGroupRequestBanned = errors.New("申请人已被禁止加入该群聊")
+)
+
+// processGroupRequest 在事务中处理一条入群申请，通过时将申请人加入群聊
+// 申请行加锁后再检查状态，避免多个管理员同时处理同一申请
+func processGroupRequest(requestID, handlerID uint64, accept bool, reason string) error {
+	return repository.DB.Transaction(func(tx *gorm.DB) error {
+		var request model.GroupRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", requestID).First(&request).Error; err != nil {
+			return err
+		}
+		if !isGroupRequestPending(&request) {
+			return errGroupRequestClosed
+		}
+		if accept && findGroupBan(request.GroupID, request.UserID) != nil {
+			return errGroupRequestBanned
+		}
+
+		now := time.Now()
+		request.Status = "rejected"
+		if accept {
+			request.Status = "accepted"
+		}
+		request.HandledBy = handlerID
+		request.HandledMessage = reason
+		request.HandledAt = &now
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+		recordGroupAudit(tx, request.GroupID, handlerID, AuditHandleRequest, request.UserID,
+			gin.H{"request_id": request.ID, "status": "pending"}, gin.H{"request_id": request.ID, "status": request.Status}, reason)
+
+		if !accept {
+			return nil
+		}
+
+		// 用户已经是群成员时无需重复添加
+		var existingMember model.GroupMember
+		if tx.Where("group_id = ? AND user_id = ?", request.GroupID, request.UserID).First(&existingMember).Error == nil {
+			return nil
+		}
+		if err := checkGroupCapacity(tx, request.GroupID); err != nil {
+			return err
+		}
+		return tx.Create(&model.GroupMember{
+			GroupID:  request.GroupID,
+			UserID:   request.UserID,
+			Role:     "member",
+			JoinedAt: now,
+		}).Error
+	})
+}
+
+// filterGroupRequests 根据查询参数筛选入群申请
+// status 可选 pending（默认）、accepted、rejected、expired、all；from/to 为申请日期（YYYY-MM-DD）；
+// applicant 为申请人ID或用户名、昵称关键词
+func filterGroupRequests(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	now := time.Now()
+	switch c.DefaultQuery("status", "pending") {
+	case "pending":
+		query = query.Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", "pending", now)
+	case "expired":
+		query = query.Where("status = ? OR (status = ? AND expires_at <= ?)", "expired", "pending", now)
+	case "accepted", "rejected":
+		query = query.Where("status = ?", c.Query("status"))
+	case "all":
+	default:
+		return nil, false
+	}
+
+	if from := c.Query("from"); from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return nil, false
+		}
+		query = query.Where("created_at >= ?", date)
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return nil, false
+		}
+		query = query.Where("created_at < ?", date.AddDate(0, 0, 1))
+	}
+
+	if applicant := c.Query("applicant"); applicant != "" {
+		if userID, err := strconv.ParseUint(applicant, 10, 64); err == nil {
+			query = query.Where("user_id = ?", userID)
+		} else {
+			keyword := "%" + applicant + "%"
+			query = query.Where("user_id IN (?)", repository.DB.Model(&model.User{}).Select("id").
+				Where("username LIKE ? OR nickname LIKE ?", keyword, keyword))
+		}
+	}
+	return query, true
+}
+
+// BatchHandleGroupRequests 批量处理群聊申请
+// 每条申请在独立事务中处理，返回每条申请的处理结果
+func BatchHandleGroupRequests(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		RequestIDs []uint64 `json:"request_ids" binding:"required,min=1"`
+		Action     string   `json:"action" binding:"required,oneof=accept reject"`
+		Reason     string   `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	if len(req.RequestIDs) > maxBatchGroupRequests {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "单次处理的申请数量过多",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有管理群成员的权限
+	if _, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageMembers); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限处理该群聊的申请",
+		})
+		return
+	}
+
+	// 只处理属于该群聊的申请
+	var groupRequestIDs []uint64
+	repository.DB.Model(&model.GroupRequest{}).Where("group_id = ? AND id IN ?", groupID, req.RequestIDs).Pluck("id", &groupRequestIDs)
+	inGroup := make(map[uint64]bool, len(groupRequestIDs))
+	for _, id := range groupRequestIDs {
+		inGroup[id] = true
+	}
+
+	accept := req.Action == "accept"
+	results := make([]gin.H, 0, len(req.RequestIDs))
+	succeeded := 0
+	handled := make(map[uint64]bool, len(req.RequestIDs))
+	for _, requestID := range req.RequestIDs {
+		if handled[requestID] {
+			continue
+		}
+		handled[requestID] = true
+
+		if !inGroup[requestID] {
+			results = append(results, gin.H{
+				"request_id": requestID,
+				"success":    false,
+				"error":      "申请不存在",
+			})
+			continue
+		}
+
+		if err := processGroupRequest(requestID, currentUserID.(uint64), accept, req.Reason); err != nil {
+			message := "处理申请失败"
+			if errors.Is(err, errGroupRequestClosed) || errors.Is(err, errGroupRequestBanned) || errors.Is(err, errGroupFull) {
+				message = err.Error()
+			}
+			results = append(results, gin.H{
+				"request_id": requestID,
+				"success":    false,
+				"error":      message,
+			})
+			continue
+		}
+
+		succeeded++
+		results = append(results, gin.H{
+			"request_id": requestID,
+			"success":    true,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "批量处理完成",
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
@@ -0,0 +1,583 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 群聊权限
+const (
+	PermEditInfo      = "edit_info"      // 修改群聊信息、群公告和群表情包
+	PermManageMembers = "manage_members" // 审批入群申请、移除成员、管理黑名单和邀请链接
+	PermMute          = "mute"           // 禁言成员和全群禁言
+	PermPin           = "pin"            // 置顶消息
+	PermRecall        = "recall"         // 撤回他人消息
+	PermInvite        = "invite"         // 邀请好友入群
+	PermManageRoles   = "manage_roles"   // 管理自定义角色、设置管理员
+)
+
+// groupPermissions 所有可分配的群聊权限
+var groupPermissions = []string{
+	PermEditInfo, PermManageMembers, PermMute, PermPin, PermRecall, PermInvite, PermManageRoles,
+}
+
+// 每个群聊最多创建的自定义角色数量
+const maxGroupRoles = 20
+
+// isGroupPermission 判断是否为有效的群聊权限
+func isGroupPermission(permission string) bool {
+	for _, p := range groupPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeGroupRolePermissions 解析角色的权限列表
+func decodeGroupRolePermissions(role *model.GroupRole) []string {
+	var permissions []string
+	if role.Permissions != "" {
+		json.Unmarshal([]byte(role.Permissions), &permissions)
+	}
+	return permissions
+}
+
+// memberPermissions 计算群成员拥有的所有权限
+// 群主和管理员拥有全部权限；普通成员在群聊允许所有人邀请时拥有邀请权限；
+// 自定义角色的权限在此基础上叠加
+func memberPermissions(group *model.Group, member *model.GroupMember) map[string]bool {
+	permissions := make(map[string]bool)
+	if member.Role == "owner" || member.Role == "admin" {
+		for _, p := range groupPermissions {
+			permissions[p] = true
+		}
+		return permissions
+	}
+
+	if group.InvitePermission != "admins" {
+		permissions[PermInvite] = true
+	}
+
+	if member.RoleID != 0 {
+		var role model.GroupRole
+		if repository.DB.Where("id = ? AND group_id = ?", member.RoleID, member.GroupID).First(&role).Error == nil {
+			for _, p := range decodeGroupRolePermissions(&role) {
+				permissions[p] = true
+			}
+		}
+	}
+	return permissions
+}
+
+// hasGroupPermission 判断群成员是否拥有指定权限
+func hasGroupPermission(group *model.Group, member *model.GroupMember, permission string) bool {
+	return memberPermissions(group, member)[permission]
+}
+
+// checkGroupPermission 群聊操作的统一鉴权入口
+// 用户是群成员且拥有指定权限时返回其成员记录
+func checkGroupPermission(groupID, userID uint64, permission string) (*model.GroupMember, bool) {
+	var member model.GroupMember
+	if err := repository.DB.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+		return nil, false
+	}
+
+	var group model.Group
+	if err := repository.DB.Select("id, invite_permission").Where("id = ?", groupID).First(&group).Error; err != nil {
+		return nil, false
+	}
+
+	if !hasGroupPermission(&group, &member, permission) {
+		return &member, false
+	}
+	return &member, true
+}
+
+// groupMemberRank 群成员的级别，群主 > 管理员 > 拥有自定义角色的成员 > 普通成员
+func groupMemberRank(member *model.GroupMember) int {
+	switch member.Role {
+	case "owner":
+		return 3
+	case "admin":
+		return 2
+	}
+	if member.RoleID != 0 {
+		return 1
+	}
+	return 0
+}
+
+// outranksGroupMember 判断操作者的级别是否高于目标成员，只能管理级别更低的成员
+func outranksGroupMember(actor, target *model.GroupMember) bool {
+	return groupMemberRank(actor) > groupMemberRank(target)
+}
+
+// parseGroupRolePermissions 校验并编码权限列表，操作者只能授予自己拥有的权限
+func parseGroupRolePermissions(permissions []string, granted map[string]bool) (string, bool) {
+	seen := make(map[string]bool, len(permissions))
+	result := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		if !isGroupPermission(p) || !granted[p] {
+			return "", false
+		}
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	data, _ := json.Marshal(result)
+	return string(data), true
+}
+
+// coversGroupRole 判断已有权限是否包含角色的全部权限
+// 只能分配、修改或删除权限不超过自己的角色
+func coversGroupRole(granted map[string]bool, role *model.GroupRole) bool {
+	for _, p := range decodeGroupRolePermissions(role) {
+		if !granted[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetGroupRoles 获取群聊自定义角色列表
+func GetGroupRoles(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var groupMember model.GroupMember
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).First(&groupMember)
+	if result.Error != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您不在该群聊中",
+		})
+		return
+	}
+
+	var roles []model.GroupRole
+	if err := repository.DB.Where("group_id = ?", groupID).Order("id ASC").Find(&roles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取群聊角色失败",
+		})
+		return
+	}
+
+	roleData := make([]gin.H, len(roles))
+	for i := range roles {
+		roleData[i] = gin.H{
+			"id":          roles[i].ID,
+			"name":        roles[i].Name,
+			"permissions": decodeGroupRolePermissions(&roles[i]),
+			"created_at":  roles[i].CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       roleData,
+		"permissions": groupPermissions,
+	})
+}
+
+// CreateGroupRole 创建群聊自定义角色
+func CreateGroupRole(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		Name        string   `json:"name" binding:"required,max=50"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageRoles)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群聊角色",
+		})
+		return
+	}
+
+	permissions, ok := parseGroupRolePermissions(req.Permissions, memberPermissions(&group, groupMember))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "包含无效权限或您未拥有的权限",
+		})
+		return
+	}
+
+	var count int64
+	repository.DB.Model(&model.GroupRole{}).Where("group_id = ?", groupID).Count(&count)
+	if count >= maxGroupRoles {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "群聊角色数量已达上限",
+		})
+		return
+	}
+
+	role := model.GroupRole{
+		GroupID:     groupID,
+		Name:        req.Name,
+		Permissions: permissions,
+		CreatedBy:   currentUserID.(uint64),
+	}
+	if err := repository.DB.Create(&role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "创建群聊角色失败",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditCreateRole, role.ID, nil, roleAuditValue(&role), "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "群聊角色创建成功",
+		"role": gin.H{
+			"id":          role.ID,
+			"name":        role.Name,
+			"permissions": decodeGroupRolePermissions(&role),
+			"created_at":  role.CreatedAt,
+		},
+	})
+}
+
+// UpdateGroupRole 修改群聊自定义角色
+func UpdateGroupRole(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	roleID, err := strconv.ParseUint(c.Param("role_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的角色ID",
+		})
+		return
+	}
+
+	var req struct {
+		Name        string   `json:"name" binding:"max=50"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageRoles)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群聊角色",
+		})
+		return
+	}
+
+	var role model.GroupRole
+	if err := repository.DB.Where("id = ? AND group_id = ?", roleID, groupID).First(&role).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊角色不存在",
+		})
+		return
+	}
+
+	// 不能修改自己所属的角色，避免自我提权
+	if groupMember.RoleID == role.ID && groupMember.Role != "owner" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "不能修改自己所属的角色",
+		})
+		return
+	}
+
+	granted := memberPermissions(&group, groupMember)
+	if !coversGroupRole(granted, &role) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "不能修改包含您未拥有权限的角色",
+		})
+		return
+	}
+
+	before := roleAuditValue(&role)
+	if req.Name != "" {
+		role.Name = req.Name
+	}
+	if req.Permissions != nil {
+		permissions, ok := parseGroupRolePermissions(req.Permissions, granted)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "包含无效权限或您未拥有的权限",
+			})
+			return
+		}
+		role.Permissions = permissions
+	}
+
+	if err := repository.DB.Save(&role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "修改群聊角色失败",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditUpdateRole, role.ID, before, roleAuditValue(&role), "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "群聊角色修改成功",
+		"role": gin.H{
+			"id":          role.ID,
+			"name":        role.Name,
+			"permissions": decodeGroupRolePermissions(&role),
+			"created_at":  role.CreatedAt,
+		},
+	})
+}
+
+// DeleteGroupRole 删除群聊自定义角色，拥有该角色的成员恢复为普通权限
+func DeleteGroupRole(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	roleID, err := strconv.ParseUint(c.Param("role_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的角色ID",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageRoles)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群聊角色",
+		})
+		return
+	}
+
+	var role model.GroupRole
+	if err := repository.DB.Where("id = ? AND group_id = ?", roleID, groupID).First(&role).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊角色不存在",
+		})
+		return
+	}
+
+	if !coversGroupRole(memberPermissions(&group, groupMember), &role) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "不能删除包含您未拥有权限的角色",
+		})
+		return
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.GroupMember{}).Where("group_id = ? AND role_id = ?", groupID, role.ID).
+			Update("role_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&role).Error; err != nil {
+			return err
+		}
+		recordGroupAudit(tx, groupID, currentUserID.(uint64), AuditDeleteRole, role.ID, roleAuditValue(&role), nil, "")
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "删除群聊角色失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "群聊角色删除成功",
+	})
+}
+
+// AssignGroupRole 为群成员分配自定义角色，role_id 为 0 时取消分配
+func AssignGroupRole(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		UserID uint64 `json:"user_id" binding:"required"`
+		RoleID uint64 `json:"role_id"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	groupMember, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermManageRoles)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群聊角色",
+		})
+		return
+	}
+
+	var targetMember model.GroupMember
+	if err := repository.DB.Where("group_id = ? AND user_id = ?", groupID, req.UserID).First(&targetMember).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "目标用户不是群成员",
+		})
+		return
+	}
+
+	if !outranksGroupMember(groupMember, &targetMember) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "只能为级别低于自己的成员分配角色",
+		})
+		return
+	}
+
+	if req.RoleID != 0 {
+		var role model.GroupRole
+		if err := repository.DB.Where("id = ? AND group_id = ?", req.RoleID, groupID).First(&role).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "群聊角色不存在",
+			})
+			return
+		}
+
+		if !coversGroupRole(memberPermissions(&group, groupMember), &role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "不能分配包含您未拥有权限的角色",
+			})
+			return
+		}
+	}
+
+	oldRoleID := targetMember.RoleID
+	targetMember.RoleID = req.RoleID
+	if err := repository.DB.Model(&targetMember).Update("role_id", req.RoleID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "分配群聊角色失败",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, groupID, currentUserID.(uint64), AuditAssignRole, targetMember.UserID,
+		gin.H{"role_id": oldRoleID}, gin.H{"role_id": req.RoleID}, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "群聊角色分配成功",
+		"member":  targetMember,
+	})
+}
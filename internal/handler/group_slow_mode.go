@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxGroupSlowMode 慢速模式最长发言间隔（秒）
+const maxGroupSlowMode = 3600
+
+// GroupSlowModePayload 慢速模式变更通知
+type GroupSlowModePayload struct {
+	GroupID  uint64 `json:"group_id"`
+	SlowMode int    `json:"slow_mode"` // 发言间隔（秒），为 0 表示关闭
+}
+
+// SetGroupSlowMode 设置群聊慢速模式，开启后普通成员在发言间隔内只能发送一条消息
+func SetGroupSlowMode(c *gin.Context) {
+	groupIDStr := c.Param("group_id")
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		SlowMode *int `json:"slow_mode" binding:"required,min=0"` // 发言间隔（秒），为 0 表示关闭
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || *req.SlowMode > maxGroupSlowMode {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	// 获取当前用户ID
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 慢速模式与全群禁言使用相同的权限
+	_, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermMute)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限设置慢速模式",
+		})
+		return
+	}
+
+	var group model.Group
+	if err := repository.DB.Where("id = ?", groupID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "群聊不存在",
+		})
+		return
+	}
+
+	before := group.SlowMode
+	group.SlowMode = *req.SlowMode
+	if err := repository.DB.Model(&group).Select("slow_mode").Updates(&group).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "设置慢速模式失败",
+		})
+		return
+	}
+
+	recordGroupAudit(repository.DB, group.ID, currentUserID.(uint64), AuditSetSlowMode, group.ID,
+		gin.H{"slow_mode": before}, gin.H{"slow_mode": group.SlowMode}, "")
+
+	notifyGroupMembers(&Manager, group.ID, WebSocketMessage{
+		Type: "group_slow_mode_updated",
+		Payload: GroupSlowModePayload{
+			GroupID:  group.ID,
+			SlowMode: group.SlowMode,
+		},
+		Timestamp: time.Now(),
+	})
+
+	actionText := "关闭"
+	if group.SlowMode > 0 {
+		actionText = "开启"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   actionText + "慢速模式成功",
+		"slow_mode": group.SlowMode,
+	})
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 每条消息最多生成预览的链接数量
+const maxLinkPreviewsPerMessage = 3
+
+var (
+	linkPreviewFetcher     *utils.LinkPreviewFetcher
+	linkPreviewFetcherOnce sync.Once
+)
+
+// getLinkPreviewFetcher 按配置懒加载链接预览抓取器
+func getLinkPreviewFetcher() *utils.LinkPreviewFetcher {
+	linkPreviewFetcherOnce.Do(func() {
+		cfg := utils.AppConfig.LinkPreview
+		linkPreviewFetcher = utils.NewLinkPreviewFetcher(
+			time.Duration(cfg.Timeout)*time.Second,
+			int64(cfg.MaxSize)*1024,
+		)
+	})
+	return linkPreviewFetcher
+}
+
+// attachLinkPreviews 后台抓取消息中链接的预览，保存后推送message_updated事件
+func attachLinkPreviews(manager *ClientManager, chatMsg ChatMessage) {
+	if !utils.AppConfig.LinkPreview.Enable || chatMsg.MessageType != "text" {
+		return
+	}
+
+	urls := utils.ExtractURLs(chatMsg.Content, maxLinkPreviewsPerMessage)
+	if len(urls) == 0 {
+		return
+	}
+
+	var previews []utils.LinkPreview
+	for _, rawURL := range urls {
+		if preview := getLinkPreview(rawURL); preview != nil {
+			previews = append(previews, *preview)
+		}
+	}
+	if len(previews) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(previews)
+	if err != nil {
+		utils.Errorf("序列化链接预览失败: %v", err)
+		return
+	}
+
+	result := repository.DB.Model(&model.Message{}).Where("id = ?", chatMsg.MessageID).Update("link_previews", string(data))
+	if result.Error != nil {
+		utils.Errorf("保存链接预览失败: %v", result.Error)
+		return
+	}
+
+	chatMsg.LinkPreviews = previews
+	updateMsg := WebSocketMessage{
+		Type:      "message_updated",
+		Payload:   chatMsg,
+		Timestamp: time.Now(),
+	}
+
+	if chatMsg.ReceiverType == "group" {
+		sendGroupChatMessage(manager, chatMsg, updateMsg)
+	} else {
+		manager.SendToUsers(updateMsg, chatMsg.SenderID, chatMsg.ReceiverID)
+	}
+}
+
+// getLinkPreview 获取链接预览，优先读取Redis缓存
+// 抓取失败的链接同样会被缓存，避免重复请求不可用的地址
+func getLinkPreview(rawURL string) *utils.LinkPreview {
+	sum := sha256.Sum256([]byte(rawURL))
+	cacheKey := "link_preview:" + hex.EncodeToString(sum[:])
+	cacheTime := time.Duration(utils.AppConfig.LinkPreview.CacheTime) * time.Minute
+	ctx := context.Background()
+
+	cached, err := repository.RDB.Get(ctx, cacheKey).Result()
+	if err == nil {
+		if cached == "" {
+			return nil
+		}
+		var preview utils.LinkPreview
+		if err := json.Unmarshal([]byte(cached), &preview); err == nil {
+			return &preview
+		}
+	} else if err != redis.Nil {
+		utils.Errorf("读取链接预览缓存失败: %v", err)
+	}
+
+	timeout := time.Duration(utils.AppConfig.LinkPreview.Timeout) * time.Second
+	fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	preview, err := getLinkPreviewFetcher().Fetch(fetchCtx, rawURL)
+	if err != nil || (preview.Title == "" && preview.Description == "") {
+		if err != nil {
+			utils.Debugf("抓取链接预览失败 %s: %v", rawURL, err)
+		}
+		repository.RDB.SetEX(ctx, cacheKey, "", cacheTime)
+		return nil
+	}
+
+	if data, err := json.Marshal(preview); err == nil {
+		repository.RDB.SetEX(ctx, cacheKey, string(data), cacheTime)
+	}
+	return preview
+}
+
+// decodeLinkPreviews 解析消息中保存的链接预览
+func decodeLinkPreviews(data string) []utils.LinkPreview {
+	if data == "" {
+		return nil
+	}
+	var previews []utils.LinkPreview
+	if err := json.Unmarshal([]byte(data), &previews); err != nil {
+		return nil
+	}
+	return previews
+}
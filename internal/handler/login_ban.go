@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// loginBanEntry 一条封禁信息
+type loginBanEntry struct {
+	key       string
+	reason    string
+	expiresIn int
+}
+
+// scanLoginBans 遍历指定前缀的封禁信息
+func scanLoginBans(ctx context.Context, prefix string) ([]loginBanEntry, error) {
+	var keys []string
+	iter := repository.RDB.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := repository.RDB.Pipeline()
+	reasons := make([]*redis.StringCmd, len(keys))
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		reasons[i] = pipe.Get(ctx, key)
+		ttls[i] = pipe.TTL(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	entries := make([]loginBanEntry, 0, len(keys))
+	for i, key := range keys {
+		// 遍历期间已过期的封禁不再返回
+		ttl := ttls[i].Val()
+		if reasons[i].Err() != nil || ttl <= 0 {
+			continue
+		}
+		entries = append(entries, loginBanEntry{
+			key:       strings.TrimPrefix(key, prefix),
+			reason:    reasons[i].Val(),
+			expiresIn: int(ttl.Seconds()),
+		})
+	}
+	return entries, nil
+}
+
+// GetLoginBans 获取被自动封禁的IP和被锁定的账号（仅系统管理员）
+func GetLoginBans(c *gin.Context) {
+	if isAdmin, _ := c.Get("is_admin"); isAdmin != true {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "只有系统管理员可以查看登录封禁",
+		})
+		return
+	}
+
+	ctx := context.Background()
+	ipBans, err := scanLoginBans(ctx, bannedIPKey(""))
+	if err != nil {
+		utils.Errorf("获取IP封禁列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取登录封禁失败",
+		})
+		return
+	}
+	accountLocks, err := scanLoginBans(ctx, "locked_account:")
+	if err != nil {
+		utils.Errorf("获取账号锁定列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取登录封禁失败",
+		})
+		return
+	}
+
+	ips := make([]gin.H, 0, len(ipBans))
+	for _, ban := range ipBans {
+		ips = append(ips, gin.H{
+			"ip":         ban.key,
+			"reason":     ban.reason,
+			"expires_in": ban.expiresIn,
+		})
+	}
+
+	userIDs := make([]uint64, 0, len(accountLocks))
+	for _, lock := range accountLocks {
+		userIDs = append(userIDs, utils.StringToUint64(lock.key))
+	}
+	var users []model.User
+	if len(userIDs) > 0 {
+		repository.DB.Select("id, username, nickname").Where("id IN ?", userIDs).Find(&users)
+	}
+	usernames := make(map[uint64]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	accounts := make([]gin.H, 0, len(accountLocks))
+	for i, lock := range accountLocks {
+		accounts = append(accounts, gin.H{
+			"user_id":    userIDs[i],
+			"username":   usernames[userIDs[i]],
+			"reason":     lock.reason,
+			"expires_in": lock.expiresIn,
+		})
+	}
+
+	allowlist := utils.AppConfig.IPBan.Allowlist
+	if allowlist == nil {
+		allowlist = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ip_bans":       ips,
+		"account_locks": accounts,
+		"allowlist":     allowlist,
+	})
+}
+
+// LiftIPBan 解除IP封禁并清空其登录失败次数（仅系统管理员）
+func LiftIPBan(c *gin.Context) {
+	if isAdmin, _ := c.Get("is_admin"); isAdmin != true {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "只有系统管理员可以解除登录封禁",
+		})
+		return
+	}
+
+	ip := net.ParseIP(c.Param("ip"))
+	if ip == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的IP地址",
+		})
+		return
+	}
+	// 与 ClientIP 返回的格式保持一致
+	ipStr := ip.String()
+
+	deleted, err := repository.RDB.Del(context.Background(), bannedIPKey(ipStr), loginFailuresIPKey(ipStr)).Result()
+	if err != nil {
+		utils.Errorf("解除IP封禁失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "解除IP封禁失败",
+		})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "该IP未被封禁",
+		})
+		return
+	}
+
+	currentUserID, _ := c.Get("user_id")
+	utils.Infof("管理员 %d 解除了IP %s 的登录封禁", currentUserID.(uint64), ipStr)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已解除IP封禁",
+	})
+}
+
+// UnlockAccount 解除账号锁定并清空其登录失败次数（仅系统管理员）
+func UnlockAccount(c *gin.Context) {
+	if isAdmin, _ := c.Get("is_admin"); isAdmin != true {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "只有系统管理员可以解除登录封禁",
+		})
+		return
+	}
+
+	userID := utils.StringToUint64(c.Param("user_id"))
+	if userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的用户ID",
+		})
+		return
+	}
+
+	deleted, err := repository.RDB.Del(context.Background(), lockedAccountKey(userID), loginFailuresAccountKey(userID)).Result()
+	if err != nil {
+		utils.Errorf("解除账号锁定失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "解除账号锁定失败",
+		})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "该账号未被锁定",
+		})
+		return
+	}
+
+	currentUserID, _ := c.Get("user_id")
+	utils.Infof("管理员 %d 解除了用户 %d 的登录锁定", currentUserID.(uint64), userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已解除账号锁定",
+	})
+}
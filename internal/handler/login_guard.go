@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// 登录日志状态
+const (
+	loginStatusSuccess = "success" // 登录成功
+	loginStatusFailed  = "failed"  // 验证失败
+	loginStatusBlocked = "blocked" // IP被封禁或账号被锁定，未进行验证
+)
+
+// 登录被拦截的原因
+const (
+	loginBlockedIPBanned      = "ip_banned"
+	loginBlockedAccountLocked = "account_locked"
+)
+
+const loginBanReason = "登录失败次数过多"
+
+// bannedIPKey 被封禁IP的 Redis Key
+func bannedIPKey(ip string) string {
+	return "banned_ip:" + ip
+}
+
+// lockedAccountKey 被锁定账号的 Redis Key
+func lockedAccountKey(userID uint64) string {
+	return fmt.Sprintf("locked_account:%d", userID)
+}
+
+// loginFailuresIPKey 同一IP登录失败次数的 Redis Key
+func loginFailuresIPKey(ip string) string {
+	return "login_failures:ip:" + ip
+}
+
+// loginFailuresAccountKey 同一账号登录失败次数的 Redis Key
+func loginFailuresAccountKey(userID uint64) string {
+	return fmt.Sprintf("login_failures:account:%d", userID)
+}
+
+// loginBanDuration 自动封禁时长
+func loginBanDuration() time.Duration {
+	minutes := utils.AppConfig.IPBan.Time
+	if minutes <= 0 {
+		minutes = 60
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// loginFailureWindow 统计登录失败次数的时间窗口
+func loginFailureWindow() time.Duration {
+	minutes := utils.AppConfig.IPBan.Window
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// isIPAllowlisted 检查IP是否在封禁白名单内，白名单支持单个IP和CIDR网段
+func isIPAllowlisted(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range utils.AppConfig.IPBan.Allowlist {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(addr) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectBlockedLogin 检查请求IP是否被封禁、账号是否被锁定
+// 被拦截时记录登录日志并直接返回 429，userID 为0时只检查IP
+func rejectBlockedLogin(c *gin.Context, userID uint64, identifier string) bool {
+	ctx := context.Background()
+	ip := c.ClientIP()
+
+	var reason string
+	var retryAfter time.Duration
+	if !isIPAllowlisted(ip) {
+		ttl, err := repository.RDB.TTL(ctx, bannedIPKey(ip)).Result()
+		if err != nil {
+			utils.Errorf("获取IP封禁信息失败: %v", err)
+		} else if ttl > 0 {
+			reason, retryAfter = loginBlockedIPBanned, ttl
+		}
+	}
+	if reason == "" && userID != 0 {
+		ttl, err := repository.RDB.TTL(ctx, lockedAccountKey(userID)).Result()
+		if err != nil {
+			utils.Errorf("获取账号锁定信息失败: %v", err)
+		} else if ttl > 0 {
+			reason, retryAfter = loginBlockedAccountLocked, ttl
+		}
+	}
+	if reason == "" {
+		return false
+	}
+
+	recordLoginAttempt(c, userID, identifier, loginStatusBlocked, reason)
+	c.JSON(429, gin.H{
+		"error":       "登录失败次数过多，请稍后再试",
+		"retry_after": int(retryAfter.Seconds()),
+	})
+	return true
+}
+
+// recordLoginFailure 记录一次登录失败，IP或账号的失败次数达到阈值时自动封禁
+// userID 为0表示账号不存在，只统计IP的失败次数
+func recordLoginFailure(c *gin.Context, userID uint64, identifier, reason string) {
+	recordLoginAttempt(c, userID, identifier, loginStatusFailed, reason)
+
+	ctx := context.Background()
+	config := utils.AppConfig.IPBan
+	ip := c.ClientIP()
+
+	if config.Number > 0 && !isIPAllowlisted(ip) {
+		if countLoginFailure(ctx, loginFailuresIPKey(ip)) >= int64(config.Number) {
+			if banLogin(ctx, bannedIPKey(ip), loginFailuresIPKey(ip)) {
+				utils.Warnf("IP %s 登录失败次数过多，已自动封禁 %d 分钟", ip, int(loginBanDuration().Minutes()))
+			}
+		}
+	}
+
+	if config.AccountNumber > 0 && userID != 0 {
+		if countLoginFailure(ctx, loginFailuresAccountKey(userID)) >= int64(config.AccountNumber) {
+			if banLogin(ctx, lockedAccountKey(userID), loginFailuresAccountKey(userID)) {
+				utils.Warnf("用户 %d 登录失败次数过多，已自动锁定 %d 分钟", userID, int(loginBanDuration().Minutes()))
+			}
+		}
+	}
+}
+
+// clearLoginFailures 验证成功后清除账号的失败次数，IP的失败次数保留到时间窗口结束
+func clearLoginFailures(userID uint64) {
+	repository.RDB.Del(context.Background(), loginFailuresAccountKey(userID))
+}
+
+// countLoginFailure 在时间窗口内累加失败次数，返回当前次数
+func countLoginFailure(ctx context.Context, key string) int64 {
+	count, err := repository.RDB.Incr(ctx, key).Result()
+	if err != nil {
+		utils.Errorf("记录登录失败次数失败: %v", err)
+		return 0
+	}
+	if count == 1 {
+		repository.RDB.Expire(ctx, key, loginFailureWindow())
+	}
+	return count
+}
+
+// banLogin 写入封禁信息并清空失败次数
+func banLogin(ctx context.Context, banKey, failuresKey string) bool {
+	_, err := repository.RDB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetEX(ctx, banKey, loginBanReason, loginBanDuration())
+		pipe.Del(ctx, failuresKey)
+		return nil
+	})
+	if err != nil {
+		utils.Errorf("写入登录封禁信息失败: %v", err)
+		return false
+	}
+	return true
+}
+
+// recordLoginAttempt 记录失败或被拦截的登录尝试
+func recordLoginAttempt(c *gin.Context, userID uint64, identifier, status, reason string) {
+	loginRecord := model.LoginRecord{
+		UserID:     userID,
+		Identifier: truncateString(identifier, 255),
+		IP:         c.ClientIP(),
+		UserAgent:  truncateString(c.GetHeader("User-Agent"), 255),
+		Status:     status,
+		Reason:     reason,
+	}
+
+	if err := repository.DB.Create(&loginRecord).Error; err != nil {
+		utils.Errorf("记录登录信息失败: %v", err)
+	}
+}
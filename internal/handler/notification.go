@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 待发送摘要邮件的用户集合
+const emailDigestPendingKey = "email_digest:pending"
+
+// NotificationPayload 新消息通知
+type NotificationPayload struct {
+	ConversationType string `json:"conversation_type"`
+	ConversationID   uint64 `json:"conversation_id"`
+	MessageID        uint64 `json:"message_id"`
+	SenderName       string `json:"sender_name"`
+	Preview          string `json:"preview"`
+}
+
+// notificationPreview 生成通知中的消息摘要
+func notificationPreview(chatMsg ChatMessage) string {
+	switch chatMsg.MessageType {
+	case "text":
+		runes := []rune(chatMsg.Content)
+		if len(runes) > 50 {
+			return string(runes[:50]) + "..."
+		}
+		return chatMsg.Content
+	case "image":
+		return "[图片]"
+	case "audio":
+		return "[语音]"
+	case "video":
+		return "[视频]"
+	case "file":
+		return "[文件] " + chatMsg.FileName
+	case "sticker":
+		return "[表情]"
+	}
+	return ""
+}
+
+// dispatchMessageNotifications 向消息接收者分发通知
+// 在线且开启桌面通知的用户收到 notification 事件，离线且开启邮件通知的用户进入摘要邮件队列
+// 对该会话开启免打扰的用户不会收到任何通知
+func dispatchMessageNotifications(manager *ClientManager, chatMsg ChatMessage) {
+	var recipientIDs []uint64
+	var conversationIDFor func(userID uint64) uint64
+
+	if chatMsg.ReceiverType == "group" {
+		repository.DB.Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id <> ?", chatMsg.ReceiverID, chatMsg.SenderID).
+			Pluck("user_id", &recipientIDs)
+		conversationIDFor = func(uint64) uint64 { return chatMsg.ReceiverID }
+
+		// 受限频道的消息只通知可访问该频道的成员
+		if allowed := restrictedChannelUserIDs(chatMsg); allowed != nil {
+			filtered := recipientIDs[:0]
+			for _, id := range recipientIDs {
+				if allowed[id] {
+					filtered = append(filtered, id)
+				}
+			}
+			recipientIDs = filtered
+		}
+	} else {
+		recipientIDs = []uint64{chatMsg.ReceiverID}
+		conversationIDFor = func(uint64) uint64 { return chatMsg.SenderID }
+	}
+	if len(recipientIDs) == 0 {
+		return
+	}
+
+	muted := mutedUserIDs(recipientIDs, chatMsg.ReceiverType, conversationIDFor)
+	online := manager.OnlineUserIDs()
+
+	var users []model.User
+	repository.DB.Select("id, email, email_verified, is_email_notification, is_desktop_notification").
+		Where("id IN ?", recipientIDs).Find(&users)
+
+	payload := NotificationPayload{
+		ConversationType: chatMsg.ReceiverType,
+		MessageID:        chatMsg.MessageID,
+		SenderName:       chatMsg.SenderName,
+		Preview:          notificationPreview(chatMsg),
+	}
+
+	ctx := context.Background()
+	for _, user := range users {
+		if muted[user.ID] {
+			continue
+		}
+
+		payload.ConversationID = conversationIDFor(user.ID)
+		if online[user.ID] {
+			if user.IsDesktopNotification {
+				manager.SendToUsers(WebSocketMessage{
+					Type:      "notification",
+					Payload:   payload,
+					Timestamp: time.Now(),
+				}, user.ID)
+			}
+			continue
+		}
+
+		if utils.AppConfig.Email.Enable && user.IsEmailNotification && user.Email != "" && user.EmailVerified {
+			digestKey := "email_digest:" + strconv.FormatUint(user.ID, 10)
+			pipe := repository.RDB.TxPipeline()
+			pipe.HIncrBy(ctx, digestKey, conversationKey(chatMsg.ReceiverType, payload.ConversationID), 1)
+			pipe.SAdd(ctx, emailDigestPendingKey, user.ID)
+			if _, err := pipe.Exec(ctx); err != nil {
+				utils.Errorf("写入消息摘要队列失败: %v", err)
+			}
+		}
+	}
+}
+
+// StartEmailDigestWorker 定时发送未读消息摘要邮件
+func StartEmailDigestWorker() {
+	interval := time.Duration(utils.AppConfig.Email.DigestInterval) * time.Minute
+	if !utils.AppConfig.Email.Enable || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sendPendingEmailDigests()
+	}
+}
+
+// sendPendingEmailDigests 发送所有待发送的摘要邮件
+func sendPendingEmailDigests() {
+	ctx := context.Background()
+	for {
+		userIDStr, err := repository.RDB.SPop(ctx, emailDigestPendingKey).Result()
+		if err == redis.Nil {
+			return
+		}
+		if err != nil {
+			utils.Errorf("读取消息摘要队列失败: %v", err)
+			return
+		}
+
+		userID := utils.StringToUint64(userIDStr)
+		digestKey := "email_digest:" + userIDStr
+
+		pipe := repository.RDB.TxPipeline()
+		countsCmd := pipe.HGetAll(ctx, digestKey)
+		pipe.Del(ctx, digestKey)
+		if _, err := pipe.Exec(ctx); err != nil {
+			utils.Errorf("读取消息摘要失败: %v", err)
+			continue
+		}
+
+		sendEmailDigest(userID, countsCmd.Val())
+	}
+}
+
+// sendEmailDigest 汇总用户的未读会话并发送摘要邮件
+func sendEmailDigest(userID uint64, counts map[string]string) {
+	var user model.User
+	if err := repository.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return
+	}
+	if !user.IsEmailNotification || user.Email == "" || !user.EmailVerified {
+		return
+	}
+
+	// 免打扰可能在排队期间被开启，发送前再次检查
+	settings := loadConversationSettings(userID)
+
+	var items []utils.DigestItem
+	for key, countStr := range counts {
+		count, _ := strconv.ParseInt(countStr, 10, 64)
+		if count <= 0 || isConversationMuted(settings[key]) {
+			continue
+		}
+
+		parts := strings.SplitN(key, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		conversationID := utils.StringToUint64(parts[1])
+
+		var name string
+		if parts[0] == "group" {
+			var group model.Group
+			if repository.DB.Select("id, name").Where("id = ?", conversationID).First(&group).Error != nil {
+				continue
+			}
+			name = "群聊 " + group.Name
+		} else {
+			var sender model.User
+			if repository.DB.Select("id, username, nickname").Where("id = ?", conversationID).First(&sender).Error != nil {
+				continue
+			}
+			name = sender.Nickname
+			if name == "" {
+				name = sender.Username
+			}
+		}
+
+		items = append(items, utils.DigestItem{Name: name, Count: count})
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	username := user.Nickname
+	if username == "" {
+		username = user.Username
+	}
+	utils.SendMessageDigestEmail(user.Email, username, items)
+}
@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	scanLoginTTL         = 5 * time.Minute  // 扫码登录二维码有效期
+	scanLoginPollTimeout = 25 * time.Second // 长轮询最长等待时间
+)
+
+// 扫码登录状态
+const (
+	scanLoginPending   = "pending"   // 等待扫码
+	scanLoginScanned   = "scanned"   // 已扫码，等待确认
+	scanLoginConfirmed = "confirmed" // 已确认，等待浏览器取走令牌
+	scanLoginCancelled = "cancelled" // 已取消
+)
+
+var errScanLoginState = errors.New("二维码状态已变化")
+
+// scanLoginKey 扫码登录会话的 Redis Key
+func scanLoginKey(qrID string) string {
+	return "scan_login:" + qrID
+}
+
+// scanLoginChannel 扫码登录状态变化的通知频道
+func scanLoginChannel(qrID string) string {
+	return "scan_login_events:" + qrID
+}
+
+// transitionScanLogin 原子地将扫码登录会话从指定状态切换到新状态，并通知等待中的浏览器
+func transitionScanLogin(qrID, from, to string, fields map[string]interface{}) error {
+	ctx := context.Background()
+	key := scanLoginKey(qrID)
+
+	err := repository.RDB.Watch(ctx, func(tx *redis.Tx) error {
+		session, err := tx.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if len(session) == 0 {
+			return redis.Nil
+		}
+		if session["status"] != from {
+			return errScanLoginState
+		}
+		// 扫码后只能由同一用户确认
+		if userID, ok := fields["user_id"]; ok && session["user_id"] != "" && session["user_id"] != userID {
+			return errScanLoginState
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			values := []interface{}{"status", to}
+			for field, value := range fields {
+				values = append(values, field, value)
+			}
+			pipe.HSet(ctx, key, values...)
+			return nil
+		})
+		return err
+	}, key)
+	if err != nil {
+		return err
+	}
+
+	repository.RDB.Publish(ctx, scanLoginChannel(qrID), to)
+	return nil
+}
+
+// CreateScanLogin 创建扫码登录二维码
+func CreateScanLogin(c *gin.Context) {
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "生成二维码失败",
+		})
+		return
+	}
+	qrID := token[:32]
+
+	ctx := context.Background()
+	key := scanLoginKey(qrID)
+	_, err = repository.RDB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"status", scanLoginPending,
+			"ip", c.ClientIP(),
+			"user_agent", c.GetHeader("User-Agent"),
+		)
+		pipe.Expire(ctx, key, scanLoginTTL)
+		return nil
+	})
+	if err != nil {
+		utils.Errorf("存储扫码登录信息失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "生成二维码失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"qr_id":      qrID,
+		"qr_content": "ventichat://login?qr_id=" + qrID,
+		"expires_in": int(scanLoginTTL.Seconds()),
+	})
+}
+
+// PollScanLogin 查询扫码登录状态，等待登录的浏览器通过长轮询获取令牌
+// 提供 last_status 时，状态未变化前最长等待 scanLoginPollTimeout
+func PollScanLogin(c *gin.Context) {
+	qrID := c.Param("qr_id")
+	lastStatus := c.Query("last_status")
+
+	ctx := c.Request.Context()
+	key := scanLoginKey(qrID)
+
+	// 先订阅再读取状态，避免错过读取后发生的状态变化
+	pubsub := repository.RDB.Subscribe(ctx, scanLoginChannel(qrID))
+	defer pubsub.Close()
+	events := pubsub.Channel()
+
+	timer := time.NewTimer(scanLoginPollTimeout)
+	defer timer.Stop()
+
+	for {
+		session, err := repository.RDB.HGetAll(ctx, key).Result()
+		if err != nil {
+			if ctx.Err() == nil {
+				utils.Errorf("获取扫码登录信息失败: %v", err)
+				c.JSON(500, gin.H{
+					"error": "服务器内部错误",
+				})
+			}
+			return
+		}
+		if len(session) == 0 {
+			c.JSON(404, gin.H{
+				"error":  "二维码已过期",
+				"status": "expired",
+			})
+			return
+		}
+
+		status := session["status"]
+		if status != lastStatus {
+			respondScanLogin(c, qrID, session)
+			return
+		}
+
+		select {
+		case <-events:
+		case <-timer.C:
+			respondScanLogin(c, qrID, session)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// respondScanLogin 返回扫码登录状态，已确认时签发令牌，令牌只能被取走一次
+func respondScanLogin(c *gin.Context, qrID string, session map[string]string) {
+	status := session["status"]
+	switch status {
+	case scanLoginConfirmed:
+		deleted, err := repository.RDB.Del(context.Background(), scanLoginKey(qrID)).Result()
+		if err != nil || deleted != 1 {
+			c.JSON(404, gin.H{
+				"error":  "二维码已过期",
+				"status": "expired",
+			})
+			return
+		}
+
+		var user model.User
+		if err := repository.DB.First(&user, utils.StringToUint64(session["user_id"])).Error; err != nil {
+			c.JSON(401, gin.H{
+				"error": "用户不存在",
+			})
+			return
+		}
+		if isLoginBanned(&user) {
+			c.JSON(401, gin.H{
+				"error": "账号已被封禁",
+			})
+			return
+		}
+
+		issueLoginToken(c, &user)
+	case scanLoginScanned:
+		var user model.User
+		repository.DB.Select("id, nickname, avatar_url").First(&user, utils.StringToUint64(session["user_id"]))
+		c.JSON(200, gin.H{
+			"status": status,
+			"user": gin.H{
+				"nickname":   user.Nickname,
+				"avatar_url": user.AvatarURL,
+			},
+		})
+	default:
+		c.JSON(200, gin.H{
+			"status": status,
+		})
+	}
+}
+
+// ScanLogin 已登录的设备扫描二维码，返回待登录设备的信息供用户确认
+func ScanLogin(c *gin.Context) {
+	qrID := c.Param("qr_id")
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	err := transitionScanLogin(qrID, scanLoginPending, scanLoginScanned, map[string]interface{}{
+		"user_id": fmt.Sprintf("%d", currentUserID.(uint64)),
+	})
+	if !respondScanLoginTransition(c, err) {
+		return
+	}
+
+	session, _ := repository.RDB.HGetAll(context.Background(), scanLoginKey(qrID)).Result()
+	c.JSON(200, gin.H{
+		"message":    "扫码成功，请确认登录",
+		"ip":         session["ip"],
+		"user_agent": session["user_agent"],
+	})
+}
+
+// ConfirmScanLogin 已扫码的设备确认或取消登录
+func ConfirmScanLogin(c *gin.Context) {
+	qrID := c.Param("qr_id")
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=confirm cancel"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	to := scanLoginConfirmed
+	if req.Action == "cancel" {
+		to = scanLoginCancelled
+	}
+
+	err := transitionScanLogin(qrID, scanLoginScanned, to, map[string]interface{}{
+		"user_id": fmt.Sprintf("%d", currentUserID.(uint64)),
+	})
+	if !respondScanLoginTransition(c, err) {
+		return
+	}
+
+	if to == scanLoginCancelled {
+		c.JSON(200, gin.H{
+			"message": "已取消登录",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "已确认登录",
+	})
+}
+
+// respondScanLoginTransition 处理扫码登录状态切换的错误，成功时返回 true
+func respondScanLoginTransition(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, redis.Nil):
+		c.JSON(404, gin.H{
+			"error": "二维码已过期",
+		})
+	case errors.Is(err, errScanLoginState), errors.Is(err, redis.TxFailedErr):
+		c.JSON(400, gin.H{
+			"error": "二维码已失效，请刷新后重试",
+		})
+	default:
+		utils.Errorf("更新扫码登录状态失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "服务器内部错误",
+		})
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindowScript 滑动窗口限流脚本
+// 移除窗口外的记录后，未达到上限时记录本次发送并返回 0，否则返回还需等待的毫秒数
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+if redis.call('ZCARD', key) < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	return 0
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local wait = tonumber(oldest[2]) + window - now
+if wait < 1 then
+	wait = 1
+end
+return wait
+`)
+
+// allowInWindow 在滑动窗口内尝试记录一次操作，返回 0 表示允许，否则返回需要等待的时间
+func allowInWindow(key string, limit int, window time.Duration) (time.Duration, error) {
+	now := time.Now()
+	wait, err := slidingWindowScript.Run(context.Background(), repository.RDB, []string{key},
+		now.UnixMilli(), window.Milliseconds(), limit, strconv.FormatInt(now.UnixNano(), 10)).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// checkSendRateLimit 检查用户的消息发送频率，超出限制时返回错误帧内容
+// member 为群聊发送者的成员信息，私聊时为空；群聊开启慢速模式时以慢速模式代替全局限制，
+// 可以设置慢速模式的成员不受慢速模式限制
+func checkSendRateLimit(userID uint64, member *model.GroupMember) *ErrorPayload {
+	var user model.User
+	if err := repository.DB.Select("id, is_freepass").Where("id = ?", userID).First(&user).Error; err == nil && user.IsFreepass {
+		return nil
+	}
+
+	key := fmt.Sprintf("send_rate:%d", userID)
+	limit := utils.AppConfig.SendFrequency.Number
+	window := time.Duration(utils.AppConfig.SendFrequency.Time) * time.Second
+	slowMode := false
+
+	if member != nil {
+		var group model.Group
+		if err := repository.DB.Select("id, invite_permission, slow_mode").Where("id = ?", member.GroupID).First(&group).Error; err == nil &&
+			group.SlowMode > 0 && !hasGroupPermission(&group, member, PermMute) {
+			key = fmt.Sprintf("slow_mode:%d:%d", member.GroupID, userID)
+			limit = 1
+			window = time.Duration(group.SlowMode) * time.Second
+			slowMode = true
+		}
+	}
+
+	if limit <= 0 || window <= 0 {
+		return nil
+	}
+
+	wait, err := allowInWindow(key, limit, window)
+	if err != nil {
+		// 限流服务不可用时不阻止发送
+		utils.Errorf("检查消息发送频率失败: %v", err)
+		return nil
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	payload := &ErrorPayload{
+		Code:       "rate_limited",
+		Message:    "发送消息过于频繁，请稍后再试",
+		RetryAfter: int(math.Ceil(wait.Seconds())),
+	}
+	if slowMode {
+		payload.Message = "群聊已开启慢速模式，请稍后再发送"
+		payload.GroupID = member.GroupID
+	}
+	return payload
+}
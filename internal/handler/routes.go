@@ -23,9 +23,18 @@ func SetupRoutes(r *gin.Engine) {
 	
 	// 注册消息相关路由
 	setupMessageRoutes(r)
+
+	// 注册表情包相关路由
+	setupStickerRoutes(r)
+
+	// 注册会话相关路由
+	setupConversationRoutes(r)
 	
 	// 注册WebSocket相关路由
 	setupWebSocketRoutes(r)
+
+	// 注册系统管理相关路由
+	setupAdminRoutes(r)
 }
 
 // 认证相关路由
@@ -36,7 +45,50 @@ func setupAuthRoutes(r *gin.Engine) {
 		auth.POST("/login", LoginUser)
 		auth.POST("/resend-verification", ResendVerificationEmail)
 		auth.POST("/verify-email", VerifyEmail)
-		auth.POST("/logout", LogoutUser) // 添加退出登录接口
+		auth.POST("/logout", LogoutUser)                         // 添加退出登录接口
+		auth.POST("/login/totp", LoginTotp)                      // 使用双因素认证验证码完成登录
+		auth.POST("/email-login/send", SendEmailLoginCode)       // 发送邮箱登录验证码
+		auth.POST("/email-login", LoginWithEmailCode)            // 使用邮箱验证码登录
+		auth.POST("/refresh", RefreshToken)                      // 使用刷新令牌换取新的访问令牌
+		auth.POST("/qr-login", CreateScanLogin)                  // 创建扫码登录二维码
+		auth.GET("/qr-login/:qr_id", PollScanLogin)              // 查询扫码登录状态（长轮询）
+		auth.POST("/webauthn/login/begin", BeginWebAuthnLogin)   // 获取通行密钥登录选项
+		auth.POST("/webauthn/login/finish", FinishWebAuthnLogin) // 使用通行密钥登录
+	}
+
+	sessions := r.Group("/api/auth/sessions")
+	sessions.Use(middleware.AuthMiddleware())
+	{
+		sessions.GET("", GetSessions)                        // 获取登录会话列表
+		sessions.DELETE("/:session_id", RevokeSession)       // 注销指定登录会话
+		sessions.POST("/revoke-others", RevokeOtherSessions) // 注销其他所有登录会话
+	}
+
+	qrLogin := r.Group("/api/auth/qr-login")
+	qrLogin.Use(middleware.AuthMiddleware())
+	{
+		qrLogin.POST("/:qr_id/scan", ScanLogin)           // 已登录设备扫描二维码
+		qrLogin.POST("/:qr_id/confirm", ConfirmScanLogin) // 已登录设备确认或取消登录
+	}
+
+	webauthn := r.Group("/api/auth/webauthn")
+	webauthn.Use(middleware.AuthMiddleware())
+	{
+		webauthn.GET("/credentials", GetWebAuthnCredentials)          // 获取通行密钥列表
+		webauthn.POST("/register/begin", BeginWebAuthnRegistration)   // 获取通行密钥注册选项
+		webauthn.POST("/register/finish", FinishWebAuthnRegistration) // 完成通行密钥注册
+		webauthn.PUT("/credentials/:id", RenameWebAuthnCredential)    // 重命名通行密钥
+		webauthn.DELETE("/credentials/:id", DeleteWebAuthnCredential) // 删除通行密钥
+	}
+
+	totp := r.Group("/api/auth/totp")
+	totp.Use(middleware.AuthMiddleware())
+	{
+		totp.GET("", GetTotpStatus)                           // 获取双因素认证状态
+		totp.POST("/setup", SetupTotp)                        // 生成双因素认证密钥
+		totp.POST("/enable", EnableTotp)                      // 确认并启用双因素认证
+		totp.POST("/disable", DisableTotp)                    // 关闭双因素认证
+		totp.POST("/recovery-codes", RegenerateRecoveryCodes) // 重新生成恢复码
 	}
 }
 
@@ -63,6 +115,14 @@ func setupFriendRoutes(r *gin.Engine) {
 		friend.GET("/:friend_id/block", BlockFriend)         // 拉黑好友
 		friend.DELETE("/:friend_id", RemoveFriend)           // 删除好友
 		friend.GET("/search", SearchUsers)                   // 搜索用户
+
+		friend.PUT("/:friend_id/remark", UpdateFriendRemark)                   // 设置好友备注
+		friend.PUT("/:friend_id/contact-groups", SetFriendContactGroups)       // 设置好友所属分组
+		friend.GET("/contact-groups", GetContactGroups)                        // 获取好友分组列表
+		friend.POST("/contact-groups", CreateContactGroup)                     // 创建好友分组
+		friend.PUT("/contact-groups/order", SortContactGroups)                 // 调整好友分组顺序
+		friend.PUT("/contact-groups/:contact_group_id", UpdateContactGroup)    // 修改好友分组
+		friend.DELETE("/contact-groups/:contact_group_id", DeleteContactGroup) // 删除好友分组
 	}
 }
 
@@ -71,19 +131,63 @@ func setupGroupRoutes(r *gin.Engine) {
 	group := r.Group("/api/groups")
 	group.Use(middleware.AuthMiddleware())
 	{
-		group.POST("/create", CreateGroup)                           // 创建群聊
-		group.GET("/list", GetGroups)                                // 获取群聊列表
-		group.GET("/:group_id/members", GetGroupMembers)             // 获取群聊成员列表
-		group.POST("/:group_id/join", JoinGroup)                     // 申请加入群聊
-		group.POST("/:group_id/leave", LeaveGroup)                   // 退出群聊
-		group.GET("/:group_id/requests", GetGroupRequests)           // 获取群聊申请列表
-		group.POST("/request/:request_id/handle", HandleGroupRequest) // 处理群聊申请
-		group.PUT("/:group_id", UpdateGroupInfo)                     // 更新群聊信息
-		group.PUT("/:group_id/transfer", TransferGroup)              // 转让群聊
-		group.POST("/:group_id/remove-member", RemoveGroupMember)    // 移除群成员
-		group.POST("/:group_id/set-role", SetGroupMemberRole)        // 设置群成员角色
-		group.POST("/:group_id/set-mute", SetGroupMute)              // 设置群成员禁言
-		group.GET("/search", SearchGroups)                           // 搜索群聊
+		group.POST("/create", CreateGroup)                                // 创建群聊
+		group.GET("/list", GetGroups)                                     // 获取群聊列表
+		group.GET("/:group_id/members", GetGroupMembers)                  // 获取群聊成员列表
+		group.POST("/:group_id/join", JoinGroup)                          // 申请加入群聊
+		group.POST("/:group_id/leave", LeaveGroup)                        // 退出群聊
+		group.GET("/:group_id/requests", GetGroupRequests)                // 获取群聊申请列表
+		group.POST("/request/:request_id/handle", HandleGroupRequest)     // 处理群聊申请
+		group.POST("/:group_id/requests/batch", BatchHandleGroupRequests) // 批量处理群聊申请
+		group.PUT("/:group_id", UpdateGroupInfo)                          // 更新群聊信息
+		group.PUT("/:group_id/transfer", TransferGroup)                   // 转让群聊
+		group.POST("/:group_id/dissolve", DissolveGroup)                  // 解散群聊
+		group.PUT("/:group_id/capacity", SetGroupCapacity)                // 设置群聊人数上限（系统管理员）
+		group.POST("/:group_id/remove-member", RemoveGroupMember)         // 移除群成员
+		group.POST("/:group_id/set-role", SetGroupMemberRole)             // 设置群成员角色
+		group.POST("/:group_id/set-mute", SetGroupMute)                   // 设置群成员禁言
+		group.POST("/:group_id/mute-all", SetGroupAllMute)                // 设置全群禁言
+		group.PUT("/:group_id/slow-mode", SetGroupSlowMode)               // 设置慢速模式
+		group.GET("/search", SearchGroups)                                // 搜索群聊
+
+		group.POST("/:group_id/invitations", InviteGroupMembers)                // 邀请好友加入群聊
+		group.GET("/invitations", GetGroupInvitations)                          // 获取收到的群聊邀请
+		group.POST("/invitations/:invitation_id/handle", HandleGroupInvitation) // 处理群聊邀请（接受/拒绝）
+
+		group.GET("/invites/:code", GetGroupInviteInfo)                     // 通过邀请码查看群聊信息
+		group.POST("/:group_id/invites", CreateGroupInvite)                 // 创建邀请链接
+		group.GET("/:group_id/invites", GetGroupInvites)                    // 获取邀请链接列表
+		group.DELETE("/:group_id/invites/:invite_id", RevokeGroupInvite)    // 撤销邀请链接
+		group.GET("/:group_id/invites/:invite_id/uses", GetGroupInviteUses) // 获取邀请链接使用记录
+
+		group.GET("/:group_id/roles", GetGroupRoles)               // 获取群聊自定义角色列表
+		group.POST("/:group_id/roles", CreateGroupRole)            // 创建群聊自定义角色
+		group.PUT("/:group_id/roles/:role_id", UpdateGroupRole)    // 修改群聊自定义角色
+		group.DELETE("/:group_id/roles/:role_id", DeleteGroupRole) // 删除群聊自定义角色
+		group.POST("/:group_id/assign-role", AssignGroupRole)      // 为群成员分配自定义角色
+
+		group.GET("/:group_id/questions", GetGroupQuestions) // 获取入群问题
+		group.PUT("/:group_id/questions", SetGroupQuestions) // 设置入群问题及审核规则
+
+		group.GET("/:group_id/announcements", GetGroupAnnouncements)                          // 获取群公告历史
+		group.POST("/:group_id/announcements", PublishGroupAnnouncement)                      // 发布群公告
+		group.POST("/:group_id/announcements/:announcement_id/ack", AckGroupAnnouncement)     // 确认已读群公告
+		group.GET("/:group_id/announcements/:announcement_id/acks", GetGroupAnnouncementAcks) // 查看群公告确认情况
+		group.GET("/:group_id/audit-logs", GetGroupAuditLogs)                                 // 获取群聊管理审计日志
+
+		group.GET("/:group_id/channels", GetGroupChannels)                           // 获取群聊频道列表及未读数
+		group.POST("/:group_id/channels", CreateGroupChannel)                        // 创建群聊频道
+		group.PUT("/:group_id/channels/:channel_id", UpdateGroupChannel)             // 修改群聊频道
+		group.DELETE("/:group_id/channels/:channel_id", DeleteGroupChannel)          // 删除群聊频道
+		group.PUT("/:group_id/channels/:channel_id/members", SetGroupChannelMembers) // 设置受限频道成员
+
+		group.POST("/:group_id/bans", BanGroupMember)              // 将用户加入群聊黑名单
+		group.GET("/:group_id/bans", GetGroupBans)                 // 获取群聊黑名单
+		group.DELETE("/:group_id/bans/:user_id", UnbanGroupMember) // 将用户移出群聊黑名单
+
+		group.GET("/:group_id/sticker-packs", GetGroupStickerPacks)                // 获取群聊启用的表情包
+		group.POST("/:group_id/sticker-packs", EnableGroupStickerPack)             // 为群聊启用表情包
+		group.DELETE("/:group_id/sticker-packs/:pack_id", DisableGroupStickerPack) // 在群聊中停用表情包
 	}
 }
 
@@ -96,6 +200,34 @@ func setupMessageRoutes(r *gin.Engine) {
 	}
 }
 
+// 表情包相关路由
+func setupStickerRoutes(r *gin.Engine) {
+	sticker := r.Group("/api/stickers")
+	sticker.Use(middleware.AuthMiddleware())
+	{
+		sticker.GET("/packs", GetStickerPacks)                                // 获取表情包列表
+		sticker.POST("/packs", CreateStickerPack)                             // 创建表情包
+		sticker.GET("/packs/:pack_id", GetStickerPack)                        // 获取表情包详情
+		sticker.PUT("/packs/:pack_id", UpdateStickerPack)                     // 修改表情包
+		sticker.DELETE("/packs/:pack_id", DeleteStickerPack)                  // 删除表情包
+		sticker.POST("/packs/:pack_id/stickers", UploadSticker)               // 上传表情
+		sticker.DELETE("/packs/:pack_id/stickers/:sticker_id", DeleteSticker) // 删除表情
+	}
+}
+
+// 会话相关路由
+func setupConversationRoutes(r *gin.Engine) {
+	conversation := r.Group("/api/conversations")
+	conversation.Use(middleware.AuthMiddleware())
+	{
+		conversation.GET("", GetConversations)                             // 获取会话概览
+		conversation.GET("/drafts", GetDrafts)                             // 获取草稿列表
+		conversation.PUT("/:type/:id/draft", SaveDraft)                    // 保存会话草稿
+		conversation.GET("/settings", GetConversationSettings)             // 获取会话设置列表
+		conversation.PUT("/:type/:id/settings", UpdateConversationSetting) // 更新会话设置
+	}
+}
+
 // WebSocket相关路由
 func setupWebSocketRoutes(r *gin.Engine) {
 	ws := r.Group("/ws")
@@ -109,4 +241,15 @@ func setupWebSocketRoutes(r *gin.Engine) {
 		ws.GET("/online-users", GetOnlineUsers)              // 获取在线用户
 		ws.GET("/user/:user_id/connections", GetUserConnections) // 获取用户连接信息
 	}
-}
\ No newline at end of file
+}
+
+// 系统管理相关路由
+func setupAdminRoutes(r *gin.Engine) {
+	admin := r.Group("/api/admin")
+	admin.Use(middleware.AuthMiddleware())
+	{
+		admin.GET("/login-bans", GetLoginBans)                       // 获取被封禁的IP和被锁定的账号
+		admin.DELETE("/login-bans/ips/:ip", LiftIPBan)               // 解除IP封禁
+		admin.DELETE("/login-bans/accounts/:user_id", UnlockAccount) // 解除账号锁定
+	}
+}
@@ -0,0 +1,415 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/token"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errRefreshTokenInvalid = errors.New("刷新令牌无效或已过期")
+
+// accessTokenTTL 访问令牌有效期
+func accessTokenTTL() time.Duration {
+	return time.Duration(utils.AppConfig.JWT.AccessExpiration) * time.Minute
+}
+
+// sessionTTL 登录会话有效期，每次刷新后重新计算
+func sessionTTL() time.Duration {
+	return time.Duration(utils.AppConfig.JWT.Expiration) * time.Hour
+}
+
+// sessionTokensKey 记录会话已签发且未过期的访问令牌，注销会话时一并删除
+func sessionTokensKey(sessionID uint64) string {
+	return fmt.Sprintf("session_tokens:%d", sessionID)
+}
+
+// sessionRotatedKey 记录会话中已被轮换掉的刷新令牌哈希，用于识别旧令牌被重复使用
+func sessionRotatedKey(sessionID uint64) string {
+	return fmt.Sprintf("session_rotated:%d", sessionID)
+}
+
+// truncateString 按字符截断字符串
+func truncateString(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+// deviceName 获取设备名称，客户端未通过 X-Device-Name 提供时根据 User-Agent 推断
+func deviceName(c *gin.Context) string {
+	if name := strings.TrimSpace(c.GetHeader("X-Device-Name")); name != "" {
+		return truncateString(name, 100)
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	system := "未知设备"
+	for _, item := range []struct{ keyword, name string }{
+		{"iPhone", "iPhone"}, {"iPad", "iPad"}, {"Android", "Android"},
+		{"Windows", "Windows"}, {"Mac OS", "macOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, item.keyword) {
+			system = item.name
+			break
+		}
+	}
+	for _, item := range []struct{ keyword, name string }{
+		{"Edg/", "Edge"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, item.keyword) {
+			return system + " " + item.name
+		}
+	}
+	return system
+}
+
+// newRefreshToken 生成刷新令牌，格式为 "<会话ID>.<随机串>"，返回令牌和随机串的哈希
+func newRefreshToken(sessionID uint64) (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	return fmt.Sprintf("%d.%s", sessionID, encoded), hashRefreshSecret(encoded), nil
+}
+
+// hashRefreshSecret 计算刷新令牌随机串的哈希
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseRefreshToken 解析刷新令牌，返回会话ID和随机串的哈希
+func parseRefreshToken(token string) (uint64, string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", errRefreshTokenInvalid
+	}
+	sessionID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", errRefreshTokenInvalid
+	}
+	return sessionID, hashRefreshSecret(parts[1]), nil
+}
+
+// createUserSession 创建登录会话并记录登录日志，返回会话和刷新令牌
+func createUserSession(c *gin.Context, userID uint64) (*model.UserSession, string, error) {
+	now := time.Now()
+	session := model.UserSession{
+		UserID:        userID,
+		LoginRecordID: recordLogin(userID, c.ClientIP(), c.GetHeader("User-Agent")),
+		DeviceName:    deviceName(c),
+		IP:            c.ClientIP(),
+		UserAgent:     truncateString(c.GetHeader("User-Agent"), 255),
+		ExpiresAt:     now.Add(sessionTTL()),
+		LastActiveAt:  now,
+	}
+	if err := repository.DB.Create(&session).Error; err != nil {
+		return nil, "", err
+	}
+
+	refreshToken, hash, err := newRefreshToken(session.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	session.RefreshTokenHash = hash
+	if err := repository.DB.Model(&session).Update("refresh_token_hash", hash).Error; err != nil {
+		return nil, "", err
+	}
+	return &session, refreshToken, nil
+}
+
+// issueAccessToken 为会话签发访问令牌并保存到 Redis
+func issueAccessToken(user *model.User, sessionID uint64) (string, error) {
+	accessToken, err := token.Issue(token.Claims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		IsAdmin:   user.IsAdmin,
+		SessionID: sessionID,
+	}, accessTokenTTL())
+	if err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+	tokensKey := sessionTokensKey(sessionID)
+	pipe := repository.RDB.TxPipeline()
+	pipe.SetEX(ctx, "token:"+accessToken, fmt.Sprintf("%d", user.ID), accessTokenTTL())
+	pipe.SAdd(ctx, tokensKey, accessToken)
+	pipe.Expire(ctx, tokensKey, accessTokenTTL())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return accessToken, nil
+}
+
+// revokeUserSessions 注销用户的指定会话：使刷新令牌和访问令牌失效，并断开这些会话的实时连接
+func revokeUserSessions(userID uint64, sessionIDs []uint64, reason string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	err := repository.DB.Model(&model.UserSession{}).
+		Where("user_id = ? AND id IN ? AND revoked_at IS NULL", userID, sessionIDs).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, sessionID := range sessionIDs {
+		tokensKey := sessionTokensKey(sessionID)
+		tokens, err := repository.RDB.SMembers(ctx, tokensKey).Result()
+		if err != nil {
+			utils.Errorf("获取会话访问令牌失败: %v", err)
+		}
+		keys := []string{tokensKey, sessionRotatedKey(sessionID)}
+		for _, accessToken := range tokens {
+			keys = append(keys, "token:"+accessToken)
+		}
+		if err := repository.RDB.Del(ctx, keys...).Err(); err != nil {
+			utils.Errorf("删除会话访问令牌失败: %v", err)
+		}
+
+		Manager.DisconnectSession(sessionID)
+	}
+	return nil
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌，刷新令牌每次使用后轮换
+// 已被轮换的旧刷新令牌再次使用时视为令牌泄露，注销整个会话
+func RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	sessionID, hash, err := parseRefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(401, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var session model.UserSession
+	if err := repository.DB.First(&session, sessionID).Error; err != nil ||
+		session.RevokedAt != nil || session.ExpiresAt.Before(time.Now()) {
+		c.JSON(401, gin.H{
+			"error": errRefreshTokenInvalid.Error(),
+		})
+		return
+	}
+
+	newToken, newHash, err := newRefreshToken(session.ID)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "生成令牌失败",
+		})
+		return
+	}
+
+	// 仅当提交的刷新令牌仍是当前令牌时才轮换，保证并发请求中只有一个成功
+	now := time.Now()
+	result := repository.DB.Model(&model.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"expires_at":         now.Add(sessionTTL()),
+			"last_active_at":     now,
+			"ip":                 c.ClientIP(),
+			"user_agent":         truncateString(c.GetHeader("User-Agent"), 255),
+		})
+	if result.Error != nil {
+		c.JSON(500, gin.H{
+			"error": "刷新令牌失败",
+		})
+		return
+	}
+	ctx := context.Background()
+	rotatedKey := sessionRotatedKey(session.ID)
+	if result.RowsAffected != 1 {
+		// 只有提交的是已被轮换掉的旧令牌才视为令牌泄露，其他不匹配的令牌直接拒绝，不影响会话
+		reused, err := repository.RDB.SIsMember(ctx, rotatedKey, hash).Result()
+		if err != nil {
+			utils.Errorf("检查刷新令牌轮换记录失败: %v", err)
+		}
+		if !reused {
+			c.JSON(401, gin.H{
+				"error": errRefreshTokenInvalid.Error(),
+			})
+			return
+		}
+
+		utils.Errorf("检测到刷新令牌被重复使用，注销会话: user=%d session=%d ip=%s", session.UserID, session.ID, c.ClientIP())
+		if err := revokeUserSessions(session.UserID, []uint64{session.ID}, "refresh_reused"); err != nil {
+			utils.Errorf("注销会话失败: %v", err)
+		}
+		c.JSON(401, gin.H{
+			"error": "刷新令牌已失效，请重新登录",
+		})
+		return
+	}
+
+	// 记录被轮换掉的令牌，保留到会话过期
+	pipe := repository.RDB.TxPipeline()
+	pipe.SAdd(ctx, rotatedKey, hash)
+	pipe.Expire(ctx, rotatedKey, sessionTTL())
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.Errorf("记录刷新令牌轮换失败: %v", err)
+	}
+
+	var user model.User
+	if err := repository.DB.First(&user, session.UserID).Error; err != nil || isLoginBanned(&user) {
+		revokeUserSessions(session.UserID, []uint64{session.ID}, "banned")
+		c.JSON(401, gin.H{
+			"error": "账号已被封禁",
+		})
+		return
+	}
+
+	accessToken, err := issueAccessToken(&user, session.ID)
+	if err != nil {
+		utils.Errorf("签发访问令牌失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "生成令牌失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"token":         accessToken,
+		"refresh_token": newToken,
+		"expires_in":    int(accessTokenTTL().Seconds()),
+		"session_id":    session.ID,
+	})
+}
+
+// GetSessions 获取当前用户的有效登录会话
+func GetSessions(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+	currentSessionID := c.GetUint64("session_id")
+
+	var sessions []model.UserSession
+	err := repository.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", currentUserID.(uint64), time.Now()).
+		Order("last_active_at DESC").Find(&sessions).Error
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "获取登录会话失败",
+		})
+		return
+	}
+
+	sessionData := make([]gin.H, len(sessions))
+	for i, session := range sessions {
+		sessionData[i] = gin.H{
+			"id":             session.ID,
+			"device_name":    session.DeviceName,
+			"ip":             session.IP,
+			"user_agent":     session.UserAgent,
+			"created_at":     session.CreatedAt,
+			"last_active_at": session.LastActiveAt,
+			"expires_at":     session.ExpiresAt,
+			"is_current":     session.ID == currentSessionID,
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"sessions": sessionData,
+	})
+}
+
+// RevokeSession 注销指定的登录会话
+func RevokeSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "无效的会话ID",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var session model.UserSession
+	if err := repository.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, currentUserID.(uint64)).
+		First(&session).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "登录会话不存在",
+		})
+		return
+	}
+
+	if err := revokeUserSessions(session.UserID, []uint64{session.ID}, "revoked"); err != nil {
+		c.JSON(500, gin.H{
+			"error": "注销登录会话失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "登录会话已注销",
+	})
+}
+
+// RevokeOtherSessions 注销除当前会话外的所有登录会话
+func RevokeOtherSessions(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+	currentSessionID := c.GetUint64("session_id")
+
+	var sessionIDs []uint64
+	repository.DB.Model(&model.UserSession{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", currentUserID.(uint64), currentSessionID).
+		Pluck("id", &sessionIDs)
+
+	if err := revokeUserSessions(currentUserID.(uint64), sessionIDs, "revoked"); err != nil {
+		c.JSON(500, gin.H{
+			"error": "注销登录会话失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":       "其他登录会话已注销",
+		"revoked_count": len(sessionIDs),
+	})
+}
@@ -0,0 +1,773 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxStickerPacksPerOwner = 50      // 每个用户或群聊最多拥有的表情包数量
+	maxStickersPerPack      = 120     // 每个表情包最多包含的表情数量
+	maxStickerSize          = 1 << 20 // 表情图片最大1MB
+	maxEmojiSize            = 256 << 10
+	maxStickerDimension     = 1024
+	maxEmojiDimension       = 256
+)
+
+// emojiNamePattern 自定义emoji短代码格式，例如 :party_parrot:
+var emojiNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{2,32}$`)
+
+// canManageStickerPack 检查用户是否有权限管理表情包
+// 个人表情包只能由本人管理，群表情包由拥有修改群聊信息权限的成员管理
+func canManageStickerPack(pack *model.StickerPack, userID uint64) bool {
+	if pack.OwnerType == "user" {
+		return pack.OwnerID == userID
+	}
+
+	_, ok := checkGroupPermission(pack.OwnerID, userID, PermEditInfo)
+	return ok
+}
+
+// canViewStickerPack 检查用户能否查看表情包
+// 个人表情包只有本人可以查看，群表情包由所属群聊和启用了该表情包的群聊成员查看
+func canViewStickerPack(pack *model.StickerPack, userID uint64) bool {
+	if pack.OwnerType == "user" && pack.OwnerID == userID {
+		return true
+	}
+
+	var count int64
+	if pack.OwnerType == "group" {
+		repository.DB.Model(&model.GroupMember{}).Where("group_id = ? AND user_id = ?", pack.OwnerID, userID).Count(&count)
+		if count > 0 {
+			return true
+		}
+	}
+	repository.DB.Model(&model.GroupStickerPack{}).
+		Joins("JOIN group_members ON group_members.group_id = group_sticker_packs.group_id").
+		Where("group_sticker_packs.pack_id = ? AND group_members.user_id = ?", pack.ID, userID).
+		Count(&count)
+	return count > 0
+}
+
+// canUseSticker 检查用户能否在指定会话中发送该表情
+func canUseSticker(sticker *model.Sticker, userID uint64, receiverType string, receiverID uint64) bool {
+	var pack model.StickerPack
+	if err := repository.DB.Where("id = ?", sticker.PackID).First(&pack).Error; err != nil {
+		return false
+	}
+	if pack.Type != "sticker" {
+		return false
+	}
+
+	// 个人表情包可以在任何会话中使用
+	if pack.OwnerType == "user" && pack.OwnerID == userID {
+		return true
+	}
+	if receiverType != "group" {
+		return false
+	}
+
+	// 群表情包和群启用的表情包只能在该群中使用
+	if pack.OwnerType == "group" && pack.OwnerID == receiverID {
+		return true
+	}
+	var count int64
+	repository.DB.Model(&model.GroupStickerPack{}).Where("group_id = ? AND pack_id = ?", receiverID, pack.ID).Count(&count)
+	return count > 0
+}
+
+// resolveStickerMessage 校验表情消息并填充表情地址，表情不可用时返回错误帧内容
+func resolveStickerMessage(chatMsg *ChatMessage, userID uint64) *ErrorPayload {
+	unavailable := &ErrorPayload{
+		Code:    "sticker_unavailable",
+		Message: "表情不存在或无法在该会话中使用",
+	}
+	if chatMsg.ReceiverType == "group" {
+		unavailable.GroupID = chatMsg.ReceiverID
+	}
+
+	if chatMsg.StickerID == 0 {
+		return unavailable
+	}
+
+	var sticker model.Sticker
+	if err := repository.DB.Where("id = ?", chatMsg.StickerID).First(&sticker).Error; err != nil {
+		return unavailable
+	}
+	if !canUseSticker(&sticker, userID, chatMsg.ReceiverType, chatMsg.ReceiverID) {
+		return unavailable
+	}
+
+	// 表情消息只引用表情ID，不接受客户端提供的文件地址
+	chatMsg.Content = ""
+	chatMsg.FileURL = ""
+	chatMsg.FileName = ""
+	chatMsg.FileSize = 0
+	chatMsg.StickerURL = sticker.FileURL
+	return nil
+}
+
+// loadStickerURLs 批量查询表情地址
+func loadStickerURLs(stickerIDs []uint64) map[uint64]string {
+	urls := make(map[uint64]string)
+	if len(stickerIDs) == 0 {
+		return urls
+	}
+
+	var stickers []model.Sticker
+	repository.DB.Select("id, file_url").Where("id IN ?", stickerIDs).Find(&stickers)
+	for _, sticker := range stickers {
+		urls[sticker.ID] = sticker.FileURL
+	}
+	return urls
+}
+
+// getStickerPackByParam 根据路径参数获取表情包
+func getStickerPackByParam(c *gin.Context) (*model.StickerPack, bool) {
+	packID, err := strconv.ParseUint(c.Param("pack_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的表情包ID",
+		})
+		return nil, false
+	}
+
+	var pack model.StickerPack
+	if err := repository.DB.Where("id = ?", packID).First(&pack).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "表情包不存在",
+		})
+		return nil, false
+	}
+	return &pack, true
+}
+
+// CreateStickerPack 创建表情包
+func CreateStickerPack(c *gin.Context) {
+	var req struct {
+		Name    string `json:"name" binding:"required,max=100"`
+		Type    string `json:"type" binding:"omitempty,oneof=sticker emoji"`
+		GroupID uint64 `json:"group_id"` // 不为空时创建群表情包
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = "sticker"
+	}
+
+	pack := model.StickerPack{
+		Name:      req.Name,
+		Type:      req.Type,
+		OwnerType: "user",
+		OwnerID:   currentUserID.(uint64),
+		CreatedBy: currentUserID.(uint64),
+	}
+	if req.GroupID != 0 {
+		pack.OwnerType = "group"
+		pack.OwnerID = req.GroupID
+		if !canManageStickerPack(&pack, currentUserID.(uint64)) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "您没有权限为该群聊创建表情包",
+			})
+			return
+		}
+	}
+
+	var packCount int64
+	repository.DB.Model(&model.StickerPack{}).Where("owner_type = ? AND owner_id = ?", pack.OwnerType, pack.OwnerID).Count(&packCount)
+	if packCount >= maxStickerPacksPerOwner {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("表情包数量已达上限（%d个）", maxStickerPacksPerOwner),
+		})
+		return
+	}
+
+	if err := repository.DB.Create(&pack).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "创建表情包失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "表情包创建成功",
+		"pack":    pack,
+	})
+}
+
+// GetStickerPacks 获取表情包列表
+// 传入group_id时返回该群可用的表情包，否则返回当前用户的个人表情包
+func GetStickerPacks(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var packs []model.StickerPack
+	query := repository.DB.Preload("Stickers")
+
+	if groupIDStr := c.Query("group_id"); groupIDStr != "" {
+		groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的群聊ID",
+			})
+			return
+		}
+
+		var groupMember model.GroupMember
+		result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).First(&groupMember)
+		if result.Error != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "您不在该群聊中",
+			})
+			return
+		}
+
+		enabledPacks := repository.DB.Model(&model.GroupStickerPack{}).Select("pack_id").Where("group_id = ?", groupID)
+		query = query.Where("(owner_type = 'group' AND owner_id = ?) OR id IN (?)", groupID, enabledPacks)
+	} else {
+		query = query.Where("owner_type = 'user' AND owner_id = ?", currentUserID.(uint64))
+	}
+
+	if err := query.Order("id ASC").Find(&packs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取表情包列表失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"packs": packs,
+	})
+}
+
+// GetStickerPack 获取表情包详情
+func GetStickerPack(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	pack, ok := getStickerPackByParam(c)
+	if !ok {
+		return
+	}
+
+	if !canViewStickerPack(pack, currentUserID.(uint64)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限查看该表情包",
+		})
+		return
+	}
+
+	if err := repository.DB.Where("pack_id = ?", pack.ID).Order("id ASC").Find(&pack.Stickers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取表情列表失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pack": pack,
+	})
+}
+
+// UpdateStickerPack 修改表情包名称
+func UpdateStickerPack(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	pack, ok := getStickerPackByParam(c)
+	if !ok {
+		return
+	}
+
+	if !canManageStickerPack(pack, currentUserID.(uint64)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限修改该表情包",
+		})
+		return
+	}
+
+	pack.Name = req.Name
+	if err := repository.DB.Save(pack).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "修改表情包失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "表情包修改成功",
+		"pack":    pack,
+	})
+}
+
+// DeleteStickerPack 删除表情包
+func DeleteStickerPack(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	pack, ok := getStickerPackByParam(c)
+	if !ok {
+		return
+	}
+
+	if !canManageStickerPack(pack, currentUserID.(uint64)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限删除该表情包",
+		})
+		return
+	}
+
+	var stickers []model.Sticker
+	repository.DB.Where("pack_id = ?", pack.ID).Find(&stickers)
+
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pack_id = ?", pack.ID).Delete(&model.GroupStickerPack{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("pack_id = ?", pack.ID).Delete(&model.Sticker{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(pack).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "删除表情包失败",
+		})
+		return
+	}
+
+	for _, sticker := range stickers {
+		utils.RemoveUploadFile(sticker.FileURL)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "表情包删除成功",
+	})
+}
+
+// UploadSticker 上传表情到表情包
+func UploadSticker(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	pack, ok := getStickerPackByParam(c)
+	if !ok {
+		return
+	}
+
+	if !canManageStickerPack(pack, currentUserID.(uint64)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限向该表情包上传表情",
+		})
+		return
+	}
+
+	name := c.PostForm("name")
+	if pack.Type == "emoji" {
+		if !emojiNamePattern.MatchString(name) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "emoji名称只能包含字母、数字和下划线，长度为2-32位",
+			})
+			return
+		}
+		var count int64
+		repository.DB.Model(&model.Sticker{}).Where("pack_id = ? AND name = ?", pack.ID, name).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "该表情包中已存在同名emoji",
+			})
+			return
+		}
+	} else if len([]rune(name)) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "表情名称不能超过50个字符",
+		})
+		return
+	}
+
+	var stickerCount int64
+	repository.DB.Model(&model.Sticker{}).Where("pack_id = ?", pack.ID).Count(&stickerCount)
+	if stickerCount >= maxStickersPerPack {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("表情包中的表情数量已达上限（%d个）", maxStickersPerPack),
+		})
+		return
+	}
+
+	maxSize, maxDimension := int64(maxStickerSize), maxStickerDimension
+	if pack.Type == "emoji" {
+		maxSize, maxDimension = maxEmojiSize, maxEmojiDimension
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请选择要上传的图片",
+		})
+		return
+	}
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("文件大小不能超过%dKB", maxSize/1024),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "读取上传文件失败",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "读取上传文件失败",
+		})
+		return
+	}
+
+	info, err := utils.ValidateImage(data, maxSize, maxDimension)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	fileURL, err := utils.SaveUploadFile(fmt.Sprintf("stickers/%d", pack.ID), info.Extension, data)
+	if err != nil {
+		utils.Errorf("保存表情文件失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "保存表情文件失败",
+		})
+		return
+	}
+
+	sticker := model.Sticker{
+		PackID:    pack.ID,
+		Name:      name,
+		FileURL:   fileURL,
+		MimeType:  info.MimeType,
+		FileSize:  int64(len(data)),
+		Width:     info.Width,
+		Height:    info.Height,
+		CreatedBy: currentUserID.(uint64),
+	}
+
+	if err := repository.DB.Create(&sticker).Error; err != nil {
+		utils.RemoveUploadFile(fileURL)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "保存表情失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "表情上传成功",
+		"sticker": sticker,
+	})
+}
+
+// DeleteSticker 从表情包中删除表情
+func DeleteSticker(c *gin.Context) {
+	stickerID, err := strconv.ParseUint(c.Param("sticker_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的表情ID",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	pack, ok := getStickerPackByParam(c)
+	if !ok {
+		return
+	}
+
+	if !canManageStickerPack(pack, currentUserID.(uint64)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限删除该表情",
+		})
+		return
+	}
+
+	var sticker model.Sticker
+	if err := repository.DB.Where("id = ? AND pack_id = ?", stickerID, pack.ID).First(&sticker).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "表情不存在",
+		})
+		return
+	}
+
+	if err := repository.DB.Delete(&sticker).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "删除表情失败",
+		})
+		return
+	}
+
+	utils.RemoveUploadFile(sticker.FileURL)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "表情删除成功",
+	})
+}
+
+// GetGroupStickerPacks 获取群聊启用的表情包
+func GetGroupStickerPacks(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var groupMember model.GroupMember
+	result := repository.DB.Where("group_id = ? AND user_id = ?", groupID, currentUserID.(uint64)).First(&groupMember)
+	if result.Error != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您不在该群聊中",
+		})
+		return
+	}
+
+	var enabled []model.GroupStickerPack
+	if err := repository.DB.Where("group_id = ?", groupID).Find(&enabled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取群表情包失败",
+		})
+		return
+	}
+
+	packIDs := make([]uint64, 0, len(enabled))
+	for _, item := range enabled {
+		packIDs = append(packIDs, item.PackID)
+	}
+
+	var packs []model.StickerPack
+	if len(packIDs) > 0 {
+		repository.DB.Where("id IN ?", packIDs).Find(&packs)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"packs": packs,
+	})
+}
+
+// EnableGroupStickerPack 为群聊启用表情包
+func EnableGroupStickerPack(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	var req struct {
+		PackID uint64 `json:"pack_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有修改群聊信息的权限
+	if _, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermEditInfo); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群表情包",
+		})
+		return
+	}
+
+	var pack model.StickerPack
+	if err := repository.DB.Where("id = ?", req.PackID).First(&pack).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "表情包不存在",
+		})
+		return
+	}
+
+	// 只能启用自己能管理的表情包，群自有表情包无需启用
+	if pack.OwnerType == "group" && pack.OwnerID == groupID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "群表情包默认已启用",
+		})
+		return
+	}
+	if !canManageStickerPack(&pack, currentUserID.(uint64)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限启用该表情包",
+		})
+		return
+	}
+
+	var count int64
+	repository.DB.Model(&model.GroupStickerPack{}).Where("group_id = ? AND pack_id = ?", groupID, pack.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "该表情包已在群聊中启用",
+		})
+		return
+	}
+
+	groupPack := model.GroupStickerPack{
+		GroupID:   groupID,
+		PackID:    pack.ID,
+		EnabledBy: currentUserID.(uint64),
+	}
+	if err := repository.DB.Create(&groupPack).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "启用表情包失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "表情包启用成功",
+	})
+}
+
+// DisableGroupStickerPack 在群聊中停用表情包
+func DisableGroupStickerPack(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的群聊ID",
+		})
+		return
+	}
+
+	packID, err := strconv.ParseUint(c.Param("pack_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的表情包ID",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	// 检查用户是否有修改群聊信息的权限
+	if _, ok := checkGroupPermission(groupID, currentUserID.(uint64), PermEditInfo); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "您没有权限管理群表情包",
+		})
+		return
+	}
+
+	result := repository.DB.Where("group_id = ? AND pack_id = ?", groupID, packID).Delete(&model.GroupStickerPack{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "停用表情包失败",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "该表情包未在群聊中启用",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "表情包已停用",
+	})
+}
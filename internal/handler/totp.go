@@ -0,0 +1,428 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	totpSetupTTL      = 10 * time.Minute // 待确认密钥有效期
+	recoveryCodeCount = 10               // 每次生成的恢复码数量
+)
+
+// verifyTotpCode 校验动态验证码，同一时间步的验证码只能使用一次
+func verifyTotpCode(user *model.User, code string) bool {
+	step, ok := utils.ValidateTOTP(user.TotpSecret, code, time.Now())
+	if !ok {
+		return false
+	}
+	usedKey := fmt.Sprintf("totp_used:%d:%d", user.ID, step)
+	fresh, err := repository.RDB.SetNX(context.Background(), usedKey, 1, 3*utils.TOTPPeriod*time.Second).Result()
+	if err != nil {
+		utils.Errorf("记录已使用的双因素认证验证码失败: %v", err)
+		return false
+	}
+	return fresh
+}
+
+// normalizeRecoveryCode 统一恢复码格式，忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// hashRecoveryCode 计算恢复码的哈希值
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// useRecoveryCode 使用一个未使用过的恢复码，每个恢复码只能使用一次
+func useRecoveryCode(userID uint64, code string) bool {
+	result := repository.DB.Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		utils.Errorf("使用恢复码失败: %v", result.Error)
+		return false
+	}
+	return result.RowsAffected == 1
+}
+
+// verifySecondFactor 校验动态验证码或恢复码
+func verifySecondFactor(user *model.User, code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		return verifyTotpCode(user, code)
+	}
+	return useRecoveryCode(user.ID, code)
+}
+
+// generateRecoveryCodes 重新生成用户的恢复码，旧的恢复码全部失效，返回明文恢复码
+func generateRecoveryCodes(tx *gorm.DB, userID uint64) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]model.UserRecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		encoded := base32.StdEncoding.EncodeToString(raw)
+		codes[i] = encoded[:4] + "-" + encoded[4:]
+		records[i] = model.UserRecoveryCode{
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(codes[i]),
+		}
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// LoginTotp 使用登录挑战令牌和双因素认证验证码完成登录
+func LoginTotp(c *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	user, ok := getLoginChallengeUser(c, req.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	if !user.IsTotpEnabled {
+		c.JSON(400, gin.H{
+			"error": "未启用双因素认证",
+		})
+		return
+	}
+
+	if !verifySecondFactor(user, req.Code) {
+		failLoginChallenge(c, user, req.ChallengeToken, "验证码错误")
+		return
+	}
+
+	clearLoginChallenge(req.ChallengeToken)
+	issueLoginToken(c, user)
+}
+
+// GetTotpStatus 获取当前用户的双因素认证状态
+func GetTotpStatus(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var user model.User
+	if err := repository.DB.First(&user, currentUserID.(uint64)).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	var remaining int64
+	if user.IsTotpEnabled {
+		repository.DB.Model(&model.UserRecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).Count(&remaining)
+	}
+
+	c.JSON(200, gin.H{
+		"is_totp_enabled":          user.IsTotpEnabled,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// SetupTotp 生成待确认的双因素认证密钥，使用验证码确认后才会启用
+func SetupTotp(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var user model.User
+	if err := repository.DB.First(&user, currentUserID.(uint64)).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	if user.IsTotpEnabled {
+		c.JSON(400, gin.H{
+			"error": "已启用双因素认证",
+		})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "生成密钥失败",
+		})
+		return
+	}
+
+	setupKey := fmt.Sprintf("totp_setup:%d", user.ID)
+	if err := repository.RDB.SetEX(context.Background(), setupKey, secret, totpSetupTTL).Err(); err != nil {
+		utils.Errorf("存储待确认的双因素认证密钥失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "生成密钥失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"secret":      secret,
+		"otpauth_uri": utils.TOTPURI(utils.AppConfig.Server.Name, user.Username, secret),
+		"expires_in":  int(totpSetupTTL.Seconds()),
+	})
+}
+
+// EnableTotp 使用验证码确认密钥并启用双因素认证，返回只显示一次的恢复码
+func EnableTotp(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var user model.User
+	if err := repository.DB.First(&user, currentUserID.(uint64)).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	if user.IsTotpEnabled {
+		c.JSON(400, gin.H{
+			"error": "已启用双因素认证",
+		})
+		return
+	}
+
+	ctx := context.Background()
+	setupKey := fmt.Sprintf("totp_setup:%d", user.ID)
+	secret, err := repository.RDB.Get(ctx, setupKey).Result()
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "密钥已过期，请重新设置",
+		})
+		return
+	}
+
+	user.TotpSecret = secret
+	if !verifyTotpCode(&user, req.Code) {
+		c.JSON(400, gin.H{
+			"error": "验证码错误",
+		})
+		return
+	}
+
+	var codes []string
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		user.IsTotpEnabled = true
+		if err := tx.Model(&user).Select("totp_secret", "is_totp_enabled").Updates(&user).Error; err != nil {
+			return err
+		}
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "启用双因素认证失败",
+		})
+		return
+	}
+
+	repository.RDB.Del(ctx, setupKey)
+
+	c.JSON(200, gin.H{
+		"message":        "双因素认证已启用，请妥善保存恢复码",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTotp 关闭双因素认证，需要验证密码以及验证码或恢复码
+func DisableTotp(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var user model.User
+	if err := repository.DB.First(&user, currentUserID.(uint64)).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	if !user.IsTotpEnabled {
+		c.JSON(400, gin.H{
+			"error": "未启用双因素认证",
+		})
+		return
+	}
+
+	// 无密码账户没有可验证的密码，仅校验验证码
+	if user.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+			c.JSON(400, gin.H{
+				"error": "密码错误",
+			})
+			return
+		}
+	}
+
+	if !verifySecondFactor(&user, req.Code) {
+		c.JSON(400, gin.H{
+			"error": "验证码错误",
+		})
+		return
+	}
+
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		user.IsTotpEnabled = false
+		user.TotpSecret = ""
+		if err := tx.Model(&user).Select("totp_secret", "is_totp_enabled").Updates(&user).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&model.UserRecoveryCode{}).Error
+	})
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "关闭双因素认证失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "双因素认证已关闭",
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，需要验证动态验证码
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var user model.User
+	if err := repository.DB.First(&user, currentUserID.(uint64)).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	if !user.IsTotpEnabled {
+		c.JSON(400, gin.H{
+			"error": "未启用双因素认证",
+		})
+		return
+	}
+
+	if !verifyTotpCode(&user, req.Code) {
+		c.JSON(400, gin.H{
+			"error": "验证码错误",
+		})
+		return
+	}
+
+	var codes []string
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "生成恢复码失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":        "恢复码已重新生成，旧的恢复码已失效",
+		"recovery_codes": codes,
+	})
+}
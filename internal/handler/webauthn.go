@@ -0,0 +1,643 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"ventichat/internal/model"
+	"ventichat/internal/repository"
+	"ventichat/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	webauthnChallengeTTL   = time.Minute // 注册和登录挑战的有效期
+	maxWebAuthnCredentials = 10          // 每个用户最多注册的通行密钥数量
+)
+
+// webauthnSession 存储在 Redis 中的挑战信息
+type webauthnSession struct {
+	UserID         uint64 `json:"user_id"`                   // 注册时为当前用户；登录时为 0 表示不限定用户
+	Purpose        string `json:"purpose"`                   // register 或 login
+	LoginChallenge string `json:"login_challenge,omitempty"` // 作为第二步验证时对应的登录挑战令牌
+	Challenge      string `json:"challenge"`                 // 签发的挑战，与客户端数据中的挑战比对
+}
+
+// webauthnCredential 浏览器返回的 PublicKeyCredential（JSON 序列化格式）
+type webauthnCredential struct {
+	ID       string `json:"id" binding:"required"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+		AttestationObject string `json:"attestationObject"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response" binding:"required"`
+}
+
+// decodeBase64URL 解码 Base64URL 字符串，兼容带填充的格式
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// webauthnUserHandle 将用户ID编码为 WebAuthn 用户句柄
+func webauthnUserHandle(userID uint64) []byte {
+	handle := make([]byte, 8)
+	binary.BigEndian.PutUint64(handle, userID)
+	return handle
+}
+
+// webauthnRPID 获取依赖方ID，未配置时使用请求的域名
+func webauthnRPID(c *gin.Context) string {
+	if utils.AppConfig.WebAuthn.RPID != "" {
+		return utils.AppConfig.WebAuthn.RPID
+	}
+	host := c.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host
+}
+
+// createWebAuthnChallenge 生成挑战并保存到 Redis
+func createWebAuthnChallenge(session webauthnSession) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	challenge := base64.RawURLEncoding.EncodeToString(raw)
+	session.Challenge = challenge
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	err = repository.RDB.SetEX(context.Background(), "webauthn_challenge:"+challenge, data, webauthnChallengeTTL).Err()
+	if err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumeWebAuthnChallenge 取出并删除挑战，每个挑战只能使用一次
+func consumeWebAuthnChallenge(challenge string) (*webauthnSession, error) {
+	ctx := context.Background()
+	key := "webauthn_challenge:" + challenge
+
+	var get *redis.StringCmd
+	_, err := repository.RDB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var session webauthnSession
+	if err := json.Unmarshal([]byte(get.Val()), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// verifyWebAuthnClientData 解析客户端数据并取出对应的挑战
+func verifyWebAuthnClientData(c *gin.Context, credential *webauthnCredential, expectedType string) ([]byte, *webauthnSession, bool) {
+	clientDataJSON, err := decodeBase64URL(credential.Response.ClientDataJSON)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return nil, nil, false
+	}
+
+	clientData, err := utils.ParseWebAuthnClientData(clientDataJSON, expectedType)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "通行密钥验证失败",
+		})
+		return nil, nil, false
+	}
+
+	session, err := consumeWebAuthnChallenge(clientData.Challenge)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "验证已过期，请重试",
+		})
+		return nil, nil, false
+	}
+
+	if err := utils.CheckWebAuthnClientData(clientData, session.Challenge, webauthnRPID(c), utils.AppConfig.WebAuthn.Origins); err != nil {
+		c.JSON(400, gin.H{
+			"error": "通行密钥验证失败",
+		})
+		return nil, nil, false
+	}
+
+	return clientDataJSON, session, true
+}
+
+// webauthnCredentialDescriptors 获取用户已注册凭证的描述，用于 allowCredentials 和 excludeCredentials
+func webauthnCredentialDescriptors(userID uint64) []gin.H {
+	var credentialIDs []string
+	repository.DB.Model(&model.WebAuthn{}).Where("user_id = ?", userID).Pluck("credential_id", &credentialIDs)
+
+	descriptors := make([]gin.H, len(credentialIDs))
+	for i, id := range credentialIDs {
+		descriptors[i] = gin.H{"type": "public-key", "id": id}
+	}
+	return descriptors
+}
+
+// GetWebAuthnCredentials 获取当前用户的通行密钥列表
+func GetWebAuthnCredentials(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var credentials []model.WebAuthn
+	if err := repository.DB.Where("user_id = ?", currentUserID.(uint64)).Order("created_at ASC").Find(&credentials).Error; err != nil {
+		c.JSON(500, gin.H{
+			"error": "获取通行密钥失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"credentials": credentials,
+	})
+}
+
+// BeginWebAuthnRegistration 生成通行密钥注册选项
+func BeginWebAuthnRegistration(c *gin.Context) {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var user model.User
+	if err := repository.DB.First(&user, currentUserID.(uint64)).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	excludeCredentials := webauthnCredentialDescriptors(user.ID)
+	if len(excludeCredentials) >= maxWebAuthnCredentials {
+		c.JSON(400, gin.H{
+			"error": "通行密钥数量已达上限",
+		})
+		return
+	}
+
+	challenge, err := createWebAuthnChallenge(webauthnSession{UserID: user.ID, Purpose: "register"})
+	if err != nil {
+		utils.Errorf("存储WebAuthn挑战失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "生成注册选项失败",
+		})
+		return
+	}
+
+	pubKeyCredParams := make([]gin.H, len(utils.WebAuthnAlgorithms))
+	for i, alg := range utils.WebAuthnAlgorithms {
+		pubKeyCredParams[i] = gin.H{"type": "public-key", "alg": alg}
+	}
+
+	c.JSON(200, gin.H{
+		"publicKey": gin.H{
+			"rp": gin.H{
+				"id":   webauthnRPID(c),
+				"name": utils.AppConfig.Server.Name,
+			},
+			"user": gin.H{
+				"id":          base64.RawURLEncoding.EncodeToString(webauthnUserHandle(user.ID)),
+				"name":        user.Username,
+				"displayName": user.Nickname,
+			},
+			"challenge":          challenge,
+			"pubKeyCredParams":   pubKeyCredParams,
+			"timeout":            webauthnChallengeTTL.Milliseconds(),
+			"attestation":        "none",
+			"excludeCredentials": excludeCredentials,
+			"authenticatorSelection": gin.H{
+				"residentKey":      "preferred",
+				"userVerification": "preferred",
+			},
+		},
+	})
+}
+
+// FinishWebAuthnRegistration 校验认证器返回的注册结果并保存通行密钥
+func FinishWebAuthnRegistration(c *gin.Context) {
+	var req struct {
+		Name       string             `json:"name" binding:"max=100"`
+		Credential webauthnCredential `json:"credential" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	_, session, ok := verifyWebAuthnClientData(c, &req.Credential, "webauthn.create")
+	if !ok {
+		return
+	}
+	if session.Purpose != "register" || session.UserID != currentUserID.(uint64) {
+		c.JSON(400, gin.H{
+			"error": "通行密钥验证失败",
+		})
+		return
+	}
+
+	attestationObject, err := decodeBase64URL(req.Credential.Response.AttestationObject)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	authData, err := utils.ParseWebAuthnAttestation(attestationObject)
+	if err == nil {
+		err = utils.CheckWebAuthnAuthData(authData, webauthnRPID(c), false)
+	}
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "通行密钥验证失败: " + err.Error(),
+		})
+		return
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(authData.CredentialID)
+	if rawID, err := decodeBase64URL(req.Credential.ID); err != nil || !bytes.Equal(rawID, authData.CredentialID) {
+		c.JSON(400, gin.H{
+			"error": "通行密钥验证失败",
+		})
+		return
+	}
+
+	var existing model.WebAuthn
+	if repository.DB.Where("credential_id = ?", credentialID).First(&existing).Error == nil {
+		c.JSON(400, gin.H{
+			"error": "该通行密钥已注册",
+		})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "通行密钥"
+	}
+
+	credential := model.WebAuthn{
+		UserID:            currentUserID.(uint64),
+		Name:              name,
+		CredentialID:      credentialID,
+		PublicKey:         base64.RawURLEncoding.EncodeToString(authData.PublicKey),
+		AttestationObject: attestationObject,
+		SignCount:         authData.SignCount,
+	}
+	if err := repository.DB.Create(&credential).Error; err != nil {
+		c.JSON(500, gin.H{
+			"error": "保存通行密钥失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":    "通行密钥注册成功",
+		"credential": credential,
+	})
+}
+
+// RenameWebAuthnCredential 修改通行密钥名称
+func RenameWebAuthnCredential(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "无效的通行密钥ID",
+		})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var credential model.WebAuthn
+	if err := repository.DB.Where("id = ? AND user_id = ?", id, currentUserID.(uint64)).First(&credential).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "通行密钥不存在",
+		})
+		return
+	}
+
+	credential.Name = strings.TrimSpace(req.Name)
+	if err := repository.DB.Model(&credential).Update("name", credential.Name).Error; err != nil {
+		c.JSON(500, gin.H{
+			"error": "修改通行密钥失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":    "通行密钥已重命名",
+		"credential": credential,
+	})
+}
+
+// DeleteWebAuthnCredential 删除通行密钥
+func DeleteWebAuthnCredential(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "无效的通行密钥ID",
+		})
+		return
+	}
+
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
+	var credential model.WebAuthn
+	if err := repository.DB.Where("id = ? AND user_id = ?", id, currentUserID.(uint64)).First(&credential).Error; err != nil {
+		c.JSON(404, gin.H{
+			"error": "通行密钥不存在",
+		})
+		return
+	}
+
+	// 无密码账户删除最后一个通行密钥后将无法使用通行密钥登录
+	var user model.User
+	repository.DB.Select("id, is_freepass").First(&user, currentUserID.(uint64))
+	if user.IsFreepass {
+		var count int64
+		repository.DB.Model(&model.WebAuthn{}).Where("user_id = ?", user.ID).Count(&count)
+		if count <= 1 {
+			c.JSON(400, gin.H{
+				"error": "无密码账户至少需要保留一个通行密钥",
+			})
+			return
+		}
+	}
+
+	if err := repository.DB.Delete(&credential).Error; err != nil {
+		c.JSON(500, gin.H{
+			"error": "删除通行密钥失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "通行密钥已删除",
+	})
+}
+
+// BeginWebAuthnLogin 生成通行密钥登录选项
+// 提供 challenge_token 时作为密码登录后的第二步验证；否则为无密码登录，可选提供用户名限定凭证
+func BeginWebAuthnLogin(c *gin.Context) {
+	var req struct {
+		Username       string `json:"username"`
+		ChallengeToken string `json:"challenge_token"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	session := webauthnSession{Purpose: "login"}
+	userVerification := "required"
+	allowCredentials := []gin.H{}
+
+	if req.ChallengeToken != "" {
+		user, ok := getLoginChallengeUser(c, req.ChallengeToken)
+		if !ok {
+			return
+		}
+		session.UserID = user.ID
+		session.LoginChallenge = req.ChallengeToken
+		userVerification = "preferred"
+		allowCredentials = webauthnCredentialDescriptors(user.ID)
+		if len(allowCredentials) == 0 {
+			c.JSON(400, gin.H{
+				"error": "未注册通行密钥",
+			})
+			return
+		}
+	} else if req.Username != "" {
+		// 用户不存在时同样返回选项，避免泄露用户名是否存在
+		var user model.User
+		if repository.DB.Select("id").Where("username = ? OR email = ?", req.Username, req.Username).First(&user).Error == nil {
+			session.UserID = user.ID
+			allowCredentials = webauthnCredentialDescriptors(user.ID)
+		}
+	}
+
+	challenge, err := createWebAuthnChallenge(session)
+	if err != nil {
+		utils.Errorf("存储WebAuthn挑战失败: %v", err)
+		c.JSON(500, gin.H{
+			"error": "生成登录选项失败",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"publicKey": gin.H{
+			"rpId":             webauthnRPID(c),
+			"challenge":        challenge,
+			"timeout":          webauthnChallengeTTL.Milliseconds(),
+			"allowCredentials": allowCredentials,
+			"userVerification": userVerification,
+		},
+	})
+}
+
+// FinishWebAuthnLogin 校验通行密钥断言并完成登录
+func FinishWebAuthnLogin(c *gin.Context) {
+	var req struct {
+		ChallengeToken string             `json:"challenge_token"`
+		Credential     webauthnCredential `json:"credential" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "请求数据格式错误",
+		})
+		return
+	}
+
+	clientDataJSON, session, ok := verifyWebAuthnClientData(c, &req.Credential, "webauthn.get")
+	if !ok {
+		return
+	}
+	if session.Purpose != "login" || session.LoginChallenge != req.ChallengeToken {
+		c.JSON(400, gin.H{
+			"error": "通行密钥验证失败",
+		})
+		return
+	}
+
+	// 作为第二步验证时，验证失败计入登录挑战的失败次数
+	secondFactor := req.ChallengeToken != ""
+	var user *model.User
+	fail := func(message string) {
+		if secondFactor {
+			failLoginChallenge(c, user, req.ChallengeToken, message)
+			return
+		}
+		c.JSON(401, gin.H{
+			"error": message,
+		})
+	}
+
+	if secondFactor {
+		if user, ok = getLoginChallengeUser(c, req.ChallengeToken); !ok {
+			return
+		}
+	}
+
+	rawID, err := decodeBase64URL(req.Credential.ID)
+	if err != nil {
+		fail("通行密钥验证失败")
+		return
+	}
+
+	var credential model.WebAuthn
+	if err := repository.DB.Where("credential_id = ?", base64.RawURLEncoding.EncodeToString(rawID)).First(&credential).Error; err != nil {
+		fail("通行密钥未注册")
+		return
+	}
+	if session.UserID != 0 && credential.UserID != session.UserID {
+		fail("通行密钥验证失败")
+		return
+	}
+
+	if req.Credential.Response.UserHandle != "" {
+		userHandle, err := decodeBase64URL(req.Credential.Response.UserHandle)
+		if err != nil || !bytes.Equal(userHandle, webauthnUserHandle(credential.UserID)) {
+			fail("通行密钥验证失败")
+			return
+		}
+	}
+
+	authenticatorData, errData := decodeBase64URL(req.Credential.Response.AuthenticatorData)
+	signature, errSig := decodeBase64URL(req.Credential.Response.Signature)
+	publicKey, errKey := decodeBase64URL(credential.PublicKey)
+	if errData != nil || errSig != nil || errKey != nil {
+		fail("通行密钥验证失败")
+		return
+	}
+
+	authData, err := utils.ParseWebAuthnAuthData(authenticatorData)
+	if err == nil {
+		// 无密码登录要求认证器验证用户身份
+		err = utils.CheckWebAuthnAuthData(authData, webauthnRPID(c), !secondFactor)
+	}
+	if err == nil {
+		err = utils.VerifyWebAuthnSignature(publicKey, authenticatorData, clientDataJSON, signature)
+	}
+	if err != nil {
+		fail("通行密钥验证失败: " + err.Error())
+		return
+	}
+
+	// 签名计数器未增长说明认证器可能被复制
+	if utils.CheckWebAuthnSignCount(credential.SignCount, authData.SignCount) != nil {
+		utils.Errorf("通行密钥签名计数器异常，可能已被复制: credential=%d stored=%d received=%d",
+			credential.ID, credential.SignCount, authData.SignCount)
+		fail("检测到通行密钥可能被复制，已拒绝登录")
+		return
+	}
+
+	now := time.Now()
+	result := repository.DB.Model(&model.WebAuthn{}).
+		Where("id = ? AND sign_count = ?", credential.ID, credential.SignCount).
+		Updates(map[string]interface{}{"sign_count": authData.SignCount, "last_used_at": now})
+	if result.Error != nil || result.RowsAffected != 1 {
+		fail("通行密钥验证失败")
+		return
+	}
+
+	if secondFactor {
+		clearLoginChallenge(req.ChallengeToken)
+		issueLoginToken(c, user)
+		return
+	}
+
+	var owner model.User
+	if err := repository.DB.First(&owner, credential.UserID).Error; err != nil {
+		c.JSON(401, gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+	if isLoginBanned(&owner) {
+		c.JSON(401, gin.H{
+			"error": "账号已被封禁",
+		})
+		return
+	}
+	// 无密码登录同样受登录失败封禁限制
+	if rejectBlockedLogin(c, owner.ID, owner.Username) {
+		return
+	}
+
+	issueLoginToken(c, &owner)
+}
@@ -12,11 +12,11 @@ import (
 
 	"ventichat/internal/model"
 	"ventichat/internal/repository"
+	"ventichat/internal/token"
 	"ventichat/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/go-redis/redis/v8"
 )
 
@@ -34,12 +34,28 @@ type ChatMessage struct {
 	SenderName   string    `json:"sender_name"`
 	ReceiverType string    `json:"receiver_type"` // 'user' 或 'group'
 	ReceiverID   uint64    `json:"receiver_id"`
+	ChannelID    uint64    `json:"channel_id,omitempty"` // 群聊频道ID，0 表示默认频道
 	Content      string    `json:"content"`
-	MessageType  string    `json:"message_type"` // 'text', 'image', 'file' 等
+	MessageType  string    `json:"message_type"` // 'text', 'image', 'file', 'sticker' 等
 	FileURL      string    `json:"file_url,omitempty"`
 	FileName     string    `json:"file_name,omitempty"`
 	FileSize     int64     `json:"file_size,omitempty"`
+	StickerID    uint64    `json:"sticker_id,omitempty"`
+	StickerURL   string    `json:"sticker_url,omitempty"`
 	SentAt       time.Time `json:"sent_at"`
+
+	LinkPreviews []utils.LinkPreview `json:"link_previews,omitempty"`
+}
+
+// 定义错误帧内容，发送被拒绝时推送给客户端
+type ErrorPayload struct {
+	Code      string     `json:"code"`    // 错误码
+	Message   string     `json:"message"` // 错误描述
+	GroupID   uint64     `json:"group_id,omitempty"`
+	ChannelID uint64     `json:"channel_id,omitempty"`
+	MuteUntil *time.Time `json:"mute_until,omitempty"` // 禁言到期时间，永久禁言时为空
+
+	RetryAfter int `json:"retry_after,omitempty"` // 发送过于频繁时需要等待的秒数
 }
 
 // 定义连接管理器
@@ -54,36 +70,11 @@ type ClientManager struct {
 
 // 客户端结构
 type Client struct {
-	conn    *websocket.Conn
-	send    chan []byte
-	userID  uint64
-	manager *ClientManager
-}
-
-// JWT声明结构
-type Claims struct {
-	Username string `json:"username"`
-	IsAdmin  bool   `json:"is_admin"`
-	jwt.RegisteredClaims
-}
-
-// validateToken 验证JWT Token
-func validateToken(tokenString string) (*Claims, error) {
-	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(utils.AppConfig.JWT.Key), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if !token.Valid {
-		return nil, err
-	}
-
-	return claims, nil
+	conn      *websocket.Conn
+	send      chan []byte
+	userID    uint64
+	sessionID uint64 // 登录会话ID，会话注销时断开连接
+	manager   *ClientManager
 }
 
 var Manager = ClientManager{
@@ -153,6 +144,92 @@ func (manager *ClientManager) SendGroupMessage(groupID uint64, message WebSocket
 	manager.mutex.RUnlock()
 }
 
+// 发送消息到群组中的指定用户，用于受限频道
+func (manager *ClientManager) SendGroupMessageToUsers(groupID uint64, message WebSocketMessage, userIDs map[uint64]bool) {
+	data := message.Encode()
+	manager.mutex.RLock()
+	for conn := range manager.groups[groupID] {
+		if !userIDs[conn.userID] {
+			continue
+		}
+		select {
+		case conn.send <- data:
+		default:
+		}
+	}
+	manager.mutex.RUnlock()
+}
+
+// 发送消息给指定用户的所有连接
+func (manager *ClientManager) SendToUsers(message WebSocketMessage, userIDs ...uint64) {
+	targets := make(map[uint64]bool, len(userIDs))
+	for _, userID := range userIDs {
+		targets[userID] = true
+	}
+
+	data := message.Encode()
+	manager.mutex.RLock()
+	for conn := range manager.clients {
+		if !targets[conn.userID] {
+			continue
+		}
+		select {
+		case conn.send <- data:
+		default:
+			// 发送缓冲区已满，跳过该连接
+		}
+	}
+	manager.mutex.RUnlock()
+}
+
+// 发送消息给指定用户除当前连接外的其他连接
+func (manager *ClientManager) SendToUserExcept(message WebSocketMessage, userID uint64, except *Client) {
+	data := message.Encode()
+	manager.mutex.RLock()
+	for conn := range manager.clients {
+		if conn.userID != userID || conn == except {
+			continue
+		}
+		select {
+		case conn.send <- data:
+		default:
+		}
+	}
+	manager.mutex.RUnlock()
+}
+
+// 获取当前在线的用户ID集合
+func (manager *ClientManager) OnlineUserIDs() map[uint64]bool {
+	online := make(map[uint64]bool)
+	manager.mutex.RLock()
+	for conn := range manager.clients {
+		online[conn.userID] = true
+	}
+	manager.mutex.RUnlock()
+	return online
+}
+
+// 发送消息给指定连接
+func (manager *ClientManager) SendToClient(message WebSocketMessage, client *Client) {
+	manager.mutex.RLock()
+	if manager.clients[client] {
+		select {
+		case client.send <- message.Encode():
+		default:
+		}
+	}
+	manager.mutex.RUnlock()
+}
+
+// 发送错误帧给指定连接
+func (client *Client) sendError(payload ErrorPayload) {
+	client.manager.SendToClient(WebSocketMessage{
+		Type:      "error",
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}, client)
+}
+
 // 添加客户端到群组
 func (manager *ClientManager) AddToGroup(groupID uint64, client *Client) {
 	manager.mutex.Lock()
@@ -175,6 +252,39 @@ func (manager *ClientManager) RemoveFromGroup(groupID uint64, client *Client) {
 	manager.mutex.Unlock()
 }
 
+// 将用户的所有连接从群组移除
+func (manager *ClientManager) RemoveUserFromGroup(groupID uint64, userID uint64) {
+	manager.mutex.Lock()
+	for client := range manager.groups[groupID] {
+		if client.userID == userID {
+			delete(manager.groups[groupID], client)
+		}
+	}
+	if len(manager.groups[groupID]) == 0 {
+		delete(manager.groups, groupID)
+	}
+	manager.mutex.Unlock()
+}
+
+// 将群组的所有连接移除，用于群聊解散
+func (manager *ClientManager) RemoveGroup(groupID uint64) {
+	manager.mutex.Lock()
+	delete(manager.groups, groupID)
+	manager.mutex.Unlock()
+}
+
+// 断开指定登录会话的所有连接
+func (manager *ClientManager) DisconnectSession(sessionID uint64) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	for conn := range manager.clients {
+		if conn.sessionID == sessionID {
+			// 关闭连接后 readPump 退出并注销客户端
+			conn.conn.Close()
+		}
+	}
+}
+
 // WebSocket连接处理
 func WebSocketHandler(c *gin.Context) {
 	// 升级HTTP连接为WebSocket连接
@@ -208,7 +318,7 @@ func WebSocketHandler(c *gin.Context) {
 	}
 
 	// 验证JWT令牌
-	_, tokenErr := validateToken(tokenString)  // 修改：使用新的变量名避免冲突
+	claims, tokenErr := token.Parse(tokenString)  // 修改：使用新的变量名避免冲突
 	if tokenErr != nil {
 		log.Printf("WebSocket连接令牌验证失败: %v", tokenErr)
 		return
@@ -229,10 +339,11 @@ func WebSocketHandler(c *gin.Context) {
 	userID := utils.StringToUint64(userIdStr)
 
 	client := &Client{
-		conn:    conn,
-		send:    make(chan []byte, 256),
-		userID:  userID,
-		manager: &Manager,
+		conn:      conn,
+		send:      make(chan []byte, 256),
+		userID:    userID,
+		sessionID: claims.SessionID,
+		manager:   &Manager,
 	}
 
 	client.manager.register <- client
@@ -295,6 +406,9 @@ func (client *Client) readPump() {
 			if groupID, ok := wsMessage.Payload.(float64); ok {
 				client.manager.RemoveFromGroup(uint64(groupID), client)
 			}
+		case "save_draft":
+			// 保存草稿并同步到其他设备
+			client.handleDraftMessage(wsMessage)
 		case "send_message":
 			// 发送消息
 			payloadBytes, _ := json.Marshal(wsMessage.Payload)
@@ -304,6 +418,7 @@ func (client *Client) readPump() {
 			}
 
 			// 验证消息是否是发送给当前用户或当前用户所在的群组
+			var senderMember *model.GroupMember
 			if chatMsg.ReceiverType == "user" {
 				// 私聊：检查当前用户是否是接收者或发送者
 				if client.userID != chatMsg.ReceiverID && client.userID != chatMsg.SenderID {
@@ -316,20 +431,63 @@ func (client *Client) readPump() {
 				if result.Error != nil {
 					continue // 用户不在群组中
 				}
+
+				// 检查用户能否访问消息所在的频道
+				if !checkGroupChannelAccess(&groupMember, chatMsg.ChannelID) {
+					client.sendError(ErrorPayload{
+						Code:      "channel_unavailable",
+						Message:   "频道不存在或无权访问",
+						GroupID:   chatMsg.ReceiverID,
+						ChannelID: chatMsg.ChannelID,
+					})
+					continue
+				}
+
+				// 检查成员禁言和全群禁言
+				if errPayload := checkGroupMute(&groupMember); errPayload != nil {
+					client.sendError(*errPayload)
+					continue
+				}
+				senderMember = &groupMember
 			} else {
 				continue // 无效的消息类型
 			}
 
+			// 频道只适用于群聊
+			if chatMsg.ReceiverType != "group" {
+				chatMsg.ChannelID = 0
+			}
+
+			// 检查发送频率和群聊慢速模式
+			if errPayload := checkSendRateLimit(client.userID, senderMember); errPayload != nil {
+				client.sendError(*errPayload)
+				continue
+			}
+
+			// 表情消息：校验表情是否可在该会话中使用
+			if chatMsg.MessageType == "sticker" {
+				if errPayload := resolveStickerMessage(&chatMsg, client.userID); errPayload != nil {
+					client.sendError(*errPayload)
+					continue
+				}
+			} else {
+				// 只有表情消息可以引用表情
+				chatMsg.StickerID = 0
+				chatMsg.StickerURL = ""
+			}
+
 			// 保存消息到数据库
 			messageModel := model.Message{
 				SenderID:     chatMsg.SenderID,
 				ReceiverType: chatMsg.ReceiverType,
 				ReceiverID:   chatMsg.ReceiverID,
+				ChannelID:    chatMsg.ChannelID,
 				MessageType:  chatMsg.MessageType,
 				Content:      chatMsg.Content,
 				FileURL:      chatMsg.FileURL,
 				FileName:     chatMsg.FileName,
 				FileSize:     chatMsg.FileSize,
+				StickerID:    chatMsg.StickerID,
 			}
 
 			result := repository.DB.Create(&messageModel)
@@ -359,11 +517,25 @@ func (client *Client) readPump() {
 
 			// 发送消息
 			if chatMsg.ReceiverType == "group" {
-				client.manager.SendGroupMessage(chatMsg.ReceiverID, returnMsg)
+				sendGroupChatMessage(client.manager, chatMsg, returnMsg)
 			} else {
 				// 私聊：发送给发送者和接收者
 				client.manager.broadcast <- returnMsg
 			}
+
+			// 消息已发送，清空该会话的草稿
+			if chatMsg.ReceiverType == "group" {
+				clearDraft(client.manager, client.userID, "group", chatMsg.ReceiverID)
+			} else {
+				clearDraft(client.manager, client.userID, "user", chatMsg.ReceiverID)
+			}
+
+			// 恢复被隐藏的会话并分发新消息通知
+			unhideConversation(chatMsg)
+			go dispatchMessageNotifications(client.manager, chatMsg)
+
+			// 后台生成链接预览
+			go attachLinkPreviews(client.manager, chatMsg)
 		}
 	}
 }
@@ -406,6 +578,18 @@ func GetChatHistory(c *gin.Context) {
 		return
 	}
 
+	// 群聊频道ID，为 0 表示默认频道
+	var channelID uint64
+	if channelIDStr := c.Query("channel_id"); channelIDStr != "" && receiverType == "group" {
+		channelID, err = strconv.ParseUint(channelIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的频道ID",
+			})
+			return
+		}
+	}
+
 	// 验证用户是否有权限查看聊天历史
 	switch receiverType {
 	case "user":
@@ -433,6 +617,13 @@ func GetChatHistory(c *gin.Context) {
 			})
 			return
 		}
+
+		if !checkGroupChannelAccess(&groupMember, channelID) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "频道不存在或无权访问",
+			})
+			return
+		}
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "无效的接收者类型",
@@ -442,12 +633,18 @@ func GetChatHistory(c *gin.Context) {
 
 	// 获取聊天记录
 	var messages []model.Message
-	err = repository.DB.Where(
-		"((sender_id = ? AND receiver_id = ? AND receiver_type = ?) OR (sender_id = ? AND receiver_id = ? AND receiver_type = ?)) OR (receiver_type = 'group' AND receiver_id = ?)",
-		userID.(uint64), receiverID, receiverType,
-		receiverID, userID.(uint64), receiverType,
-		receiverID,
-	).Order("sent_at ASC").Find(&messages).Error
+	query := repository.DB
+	if receiverType == "group" {
+		// 群聊只返回当前频道的消息
+		query = query.Where("receiver_type = 'group' AND receiver_id = ? AND channel_id = ?", receiverID, channelID)
+	} else {
+		query = query.Where(
+			"(sender_id = ? AND receiver_id = ? AND receiver_type = ?) OR (sender_id = ? AND receiver_id = ? AND receiver_type = ?)",
+			userID.(uint64), receiverID, receiverType,
+			receiverID, userID.(uint64), receiverType,
+		)
+	}
+	err = query.Order("sent_at ASC").Find(&messages).Error
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -456,33 +653,61 @@ func GetChatHistory(c *gin.Context) {
 		return
 	}
 
+	// 批量查询表情消息的表情地址
+	var stickerIDs []uint64
+	for _, msg := range messages {
+		if msg.MessageType == "sticker" && msg.StickerID != 0 {
+			stickerIDs = append(stickerIDs, msg.StickerID)
+		}
+	}
+	stickerURLs := loadStickerURLs(stickerIDs)
+
+	// 好友备注名优先于昵称显示
+	remarks := loadFriendRemarks(userID.(uint64))
+
 	// 转换为前端需要的格式
 	var chatHistory []ChatMessage
 	for _, msg := range messages {
 		var senderName string
 		var sender model.User
 		repository.DB.Select("id, username, nickname").Where("id = ?", msg.SenderID).First(&sender)
-		if sender.Nickname != "" {
+		if remark, ok := remarks[msg.SenderID]; ok {
+			senderName = remark
+		} else if sender.Nickname != "" {
 			senderName = sender.Nickname
 		} else {
 			senderName = sender.Username
 		}
 
+		var stickerID uint64
+		if msg.MessageType == "sticker" {
+			stickerID = msg.StickerID
+		}
+
 		chatHistory = append(chatHistory, ChatMessage{
 			MessageID:    msg.ID,
 			SenderID:     msg.SenderID,
 			SenderName:   senderName,
 			ReceiverType: msg.ReceiverType,
 			ReceiverID:   msg.ReceiverID,
+			ChannelID:    msg.ChannelID,
 			Content:      msg.Content,
 			MessageType:  msg.MessageType,
 			FileURL:      msg.FileURL,
 			FileName:     msg.FileName,
 			FileSize:     msg.FileSize,
+			StickerID:    stickerID,
+			StickerURL:   stickerURLs[stickerID],
 			SentAt:       msg.SentAt,
+			LinkPreviews: decodeLinkPreviews(msg.LinkPreviews),
 		})
 	}
 
+	// 查看群聊频道的历史记录时更新已读位置
+	if receiverType == "group" && len(messages) > 0 {
+		markGroupChannelRead(receiverID, channelID, userID.(uint64), messages[len(messages)-1].ID)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"messages": chatHistory,
 	})
@@ -7,10 +7,10 @@ import (
 
 	"ventichat/internal/repository"
 	"ventichat/internal/model"
+	"ventichat/internal/token"
 	"ventichat/internal/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/go-redis/redis/v8"
 )
 
@@ -40,7 +40,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// 验证Token是否有效
-		claims, err := validateToken(tokenString)
+		claims, err := token.Parse(tokenString)
 		if err != nil {
 			c.JSON(401, gin.H{
 				"error": "无效的访问令牌",
@@ -91,37 +91,12 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", userID)
 		c.Set("username", claims.Username)
 		c.Set("is_admin", claims.IsAdmin)
+		c.Set("session_id", claims.SessionID)
 
 		c.Next()
 	}
 }
 
-// Claims JWT声明结构
-type Claims struct {
-	Username string `json:"username"`
-	IsAdmin  bool   `json:"is_admin"`
-	jwt.RegisteredClaims
-}
-
-// validateToken 验证JWT Token
-func validateToken(tokenString string) (*Claims, error) {
-	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(utils.AppConfig.JWT.Key), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if !token.Valid {
-		return nil, err
-	}
-
-	return claims, nil
-}
-
 // isUserBanned 检查用户是否被封禁
 func isUserBanned(userID uint64) bool {
 	var user model.User
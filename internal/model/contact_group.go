@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// ContactGroup 好友分组表
+type ContactGroup struct {
+	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	UserID    uint64    `gorm:"type:bigint unsigned;not null;index" json:"user_id"`
+	Name      string    `gorm:"type:varchar(50);not null" json:"name"`
+	SortOrder int       `gorm:"type:int;not null;default:0" json:"sort_order"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ContactGroupMember 好友分组成员表
+type ContactGroupMember struct {
+	ID             uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	ContactGroupID uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_contact_group_friend" json:"contact_group_id"`
+	UserID         uint64    `gorm:"type:bigint unsigned;not null;index" json:"user_id"`
+	FriendID       uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_contact_group_friend" json:"friend_id"`
+	CreatedAt      time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
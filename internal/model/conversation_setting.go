@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// ConversationSetting 用户会话设置表
+type ConversationSetting struct {
+	ID               uint64     `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	UserID           uint64     `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_user_conversation" json:"user_id"`
+	ConversationType string     `gorm:"type:enum('user','group');not null;uniqueIndex:idx_user_conversation" json:"conversation_type"`
+	ConversationID   uint64     `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_user_conversation" json:"conversation_id"`
+	IsMuted          bool       `gorm:"type:boolean;not null;default:false" json:"is_muted"`
+	MutedUntil       *time.Time `gorm:"type:timestamp" json:"muted_until"`
+	IsPinned         bool       `gorm:"type:boolean;not null;default:false" json:"is_pinned"`
+	PinnedAt         *time.Time `gorm:"type:timestamp" json:"pinned_at"`
+	IsArchived       bool       `gorm:"type:boolean;not null;default:false" json:"is_archived"`
+	IsHidden         bool       `gorm:"type:boolean;not null;default:false" json:"is_hidden"`
+	SortOrder        int        `gorm:"type:int;not null;default:0" json:"sort_order"`
+	UpdatedAt        time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// Draft 会话草稿表
+type Draft struct {
+	ID               uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	UserID           uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_user_conversation" json:"user_id"`
+	ConversationType string    `gorm:"type:enum('user','group');not null;uniqueIndex:idx_user_conversation" json:"conversation_type"`
+	ConversationID   uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_user_conversation" json:"conversation_id"`
+	Content          string    `gorm:"type:text" json:"content"`
+	UpdatedAt        time.Time `gorm:"type:timestamp(3);not null;autoUpdateTime:false" json:"updated_at"`
+}
@@ -11,6 +11,8 @@ type Friend struct {
 	FriendID  uint64    `gorm:"type:bigint unsigned;not null;index" json:"friend_id"`
 	Status    string    `gorm:"type:enum('active','block');not null;default:'active'" json:"status"`
 	Unread    int       `gorm:"type:int;not null;default:0" json:"unread"`
+	Remark    string    `gorm:"type:varchar(100)" json:"remark"`
+	Note      string    `gorm:"type:text" json:"note"`
 	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	User      User      `gorm:"foreignKey:UserID;references:ID" json:"user"`
 	Friend    User      `gorm:"foreignKey:FriendID;references:ID" json:"friend"`
@@ -6,27 +6,31 @@ import (
 
 // Group 群聊表
 type Group struct {
-	ID              uint64     `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
-	Name            string     `gorm:"type:varchar(100);not null" json:"name"`
-	AvatarURL       string     `gorm:"type:varchar(255);not null;default:'/default/group.png'" json:"avatar_url"`
-	OwnerID         uint64     `gorm:"type:bigint unsigned;not null" json:"owner_id"`
-	Description     string     `gorm:"type:text" json:"description"`
-	Announcement    string     `gorm:"type:text" json:"announcement"`
-	NeedApproval    bool       `gorm:"type:boolean;not null;default:true" json:"need_approval"`
-	IsPrivate       bool       `gorm:"type:boolean;not null;default:false" json:"is_private"`
-	IsMute          bool       `gorm:"type:boolean;not null;default:false" json:"is_mute"`
-	MuteAt          *time.Time `gorm:"type:timestamp" json:"mute_at"`
-	MuteReason      string     `gorm:"type:text" json:"mute_reason"`
-	MutePermanent   bool       `gorm:"type:boolean;default:false" json:"mute_permanent"`
-	MuteBy          uint64     `gorm:"type:bigint unsigned" json:"mute_by"`
-	MuteTexpires    *time.Time `gorm:"type:timestamp" json:"mute_texpires"`
-	IsBanned        bool       `gorm:"type:boolean;not null;default:false" json:"is_banned"`
-	BannedAt        *time.Time `gorm:"type:timestamp" json:"banned_at"`
-	BannedReason    string     `gorm:"type:text" json:"banned_reason"`
-	BannedPermanent bool       `gorm:"type:boolean;default:false" json:"banned_permanent"`
-	BannedBy        uint64     `gorm:"type:bigint unsigned" json:"banned_by"`
-	BannedTexpires  *time.Time `gorm:"type:timestamp" json:"banned_texpires"`
-	CreatedAt       time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	BlockUsers      string     `gorm:"type:json" json:"block_users"`
-	BackgroundURL   string     `gorm:"type:varchar(255);default:'/default/background-group.png'" json:"background_url"`
+	ID               uint64     `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	Name             string     `gorm:"type:varchar(100);not null" json:"name"`
+	AvatarURL        string     `gorm:"type:varchar(255);not null;default:'/default/group.png'" json:"avatar_url"`
+	OwnerID          uint64     `gorm:"type:bigint unsigned;not null" json:"owner_id"`
+	Description      string     `gorm:"type:text" json:"description"`
+	Announcement     string     `gorm:"type:text" json:"announcement"`
+	NeedApproval     bool       `gorm:"type:boolean;not null;default:true" json:"need_approval"`
+	IsPrivate        bool       `gorm:"type:boolean;not null;default:false" json:"is_private"`
+	InvitePermission string     `gorm:"type:enum('anyone','admins');not null;default:'anyone'" json:"invite_permission"`
+	AutoApprove      bool       `gorm:"type:boolean;not null;default:false" json:"auto_approve"` // 入群问题全部答对时自动通过申请
+	RequestTTL       int64      `gorm:"type:bigint;not null;default:0" json:"request_ttl"`       // 入群申请的有效期（秒），为 0 表示不过期
+	MaxMembers       int        `gorm:"type:int;not null;default:0" json:"max_members"`          // 最大成员数，为 0 表示使用服务器默认值
+	SlowMode         int        `gorm:"type:int;not null;default:0" json:"slow_mode"`            // 慢速模式发言间隔（秒），为 0 表示关闭
+	IsMute           bool       `gorm:"type:boolean;not null;default:false" json:"is_mute"`
+	MuteAt           *time.Time `gorm:"type:timestamp" json:"mute_at"`
+	MuteReason       string     `gorm:"type:text" json:"mute_reason"`
+	MutePermanent    bool       `gorm:"type:boolean;default:false" json:"mute_permanent"`
+	MuteBy           uint64     `gorm:"type:bigint unsigned" json:"mute_by"`
+	MuteTexpires     *time.Time `gorm:"type:timestamp" json:"mute_texpires"`
+	IsBanned         bool       `gorm:"type:boolean;not null;default:false" json:"is_banned"`
+	BannedAt         *time.Time `gorm:"type:timestamp" json:"banned_at"`
+	BannedReason     string     `gorm:"type:text" json:"banned_reason"`
+	BannedPermanent  bool       `gorm:"type:boolean;default:false" json:"banned_permanent"`
+	BannedBy         uint64     `gorm:"type:bigint unsigned" json:"banned_by"`
+	BannedTexpires   *time.Time `gorm:"type:timestamp" json:"banned_texpires"`
+	CreatedAt        time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	BackgroundURL    string     `gorm:"type:varchar(255);default:'/default/background-group.png'" json:"background_url"`
 }
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// GroupAnnouncement 群公告表，每次发布公告新增一条记录
+type GroupAnnouncement struct {
+	ID         uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID    uint64    `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
+	Content    string    `gorm:"type:text;not null" json:"content"`
+	AuthorID   uint64    `gorm:"type:bigint unsigned;not null" json:"author_id"`
+	RequireAck bool      `gorm:"type:boolean;not null;default:false" json:"require_ack"` // 是否要求成员确认已读
+	CreatedAt  time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// GroupAnnouncementAck 群公告确认记录表
+type GroupAnnouncementAck struct {
+	ID             uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	AnnouncementID uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_announcement_ack" json:"announcement_id"`
+	GroupID        uint64    `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
+	UserID         uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_announcement_ack" json:"user_id"`
+	AckedAt        time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"acked_at"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+)
+
+// GroupAuditLog 群聊管理操作审计日志表，只追加不修改
+type GroupAuditLog struct {
+	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID   uint64    `gorm:"type:bigint unsigned;not null;index:idx_group_audit_log" json:"group_id"`
+	ActorID   uint64    `gorm:"type:bigint unsigned;not null;index" json:"actor_id"`
+	Action    string    `gorm:"type:varchar(50);not null" json:"action"`
+	TargetID  uint64    `gorm:"type:bigint unsigned;not null;default:0" json:"target_id"` // 操作对象ID，含义由 action 决定
+	Before    string    `gorm:"type:text" json:"before"`                                  // 操作前的值（JSON格式）
+	After     string    `gorm:"type:text" json:"after"`                                   // 操作后的值（JSON格式）
+	Reason    string    `gorm:"type:text" json:"reason"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP;index:idx_group_audit_log" json:"created_at"`
+}
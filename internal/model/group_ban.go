@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+)
+
+// GroupBan 群聊黑名单表
+type GroupBan struct {
+	ID        uint64     `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID   uint64     `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_group_ban_user" json:"group_id"`
+	UserID    uint64     `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_group_ban_user" json:"user_id"`
+	Reason    string     `gorm:"type:text" json:"reason"`
+	BannedBy  uint64     `gorm:"type:bigint unsigned;not null" json:"banned_by"`
+	ExpiresAt *time.Time `gorm:"type:timestamp" json:"expires_at"`
+	CreatedAt time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
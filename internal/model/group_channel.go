@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+)
+
+// GroupChannel 群聊频道表
+// 频道ID为 0 表示群聊的默认消息流，不在本表中存储
+type GroupChannel struct {
+	ID           uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID      uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_group_channel_name" json:"group_id"`
+	Name         string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_group_channel_name" json:"name"`
+	Description  string    `gorm:"type:varchar(255)" json:"description"`
+	IsRestricted bool      `gorm:"type:boolean;not null;default:false" json:"is_restricted"` // 是否仅限频道成员访问
+	SortOrder    int       `gorm:"type:int;not null;default:0" json:"sort_order"`
+	CreatedBy    uint64    `gorm:"type:bigint unsigned;not null" json:"created_by"`
+	CreatedAt    time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// GroupChannelMember 受限频道成员表
+type GroupChannelMember struct {
+	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	ChannelID uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_channel_member" json:"channel_id"`
+	GroupID   uint64    `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
+	UserID    uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_channel_member" json:"user_id"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// GroupChannelRead 群聊频道已读位置表，用于计算各频道的未读消息数
+type GroupChannelRead struct {
+	ID         uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID    uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_channel_read" json:"group_id"`
+	ChannelID  uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_channel_read" json:"channel_id"`
+	UserID     uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_channel_read" json:"user_id"`
+	LastReadID uint64    `gorm:"type:bigint unsigned;not null;default:0" json:"last_read_id"` // 最后已读的消息ID
+	UpdatedAt  time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
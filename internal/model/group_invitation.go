@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+// GroupInvitation 群聊成员邀请表
+type GroupInvitation struct {
+	ID        uint64     `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID   uint64     `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
+	InviterID uint64     `gorm:"type:bigint unsigned;not null" json:"inviter_id"`
+	InviteeID uint64     `gorm:"type:bigint unsigned;not null;index" json:"invitee_id"`
+	Message   string     `gorm:"type:text" json:"message"`
+	Status    string     `gorm:"type:enum('pending','accepted','declined');not null;default:'pending'" json:"status"`
+	CreatedAt time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	HandledAt *time.Time `gorm:"type:timestamp" json:"handled_at"`
+}
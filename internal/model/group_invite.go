@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// GroupInvite 群聊邀请链接表
+type GroupInvite struct {
+	ID              uint64     `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID         uint64     `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
+	Code            string     `gorm:"type:varchar(32);not null;uniqueIndex" json:"code"`
+	CreatedBy       uint64     `gorm:"type:bigint unsigned;not null" json:"created_by"`
+	ExpiresAt       *time.Time `gorm:"type:timestamp" json:"expires_at"`
+	MaxUses         int        `gorm:"type:int;not null;default:0" json:"max_uses"`
+	UseCount        int        `gorm:"type:int;not null;default:0" json:"use_count"`
+	RequireApproval bool       `gorm:"type:boolean;not null;default:false" json:"require_approval"` // 默认值必须为 false，否则 GORM 创建时会把 false 替换为默认值
+	IsRevoked       bool       `gorm:"type:boolean;not null;default:false" json:"is_revoked"`
+	RevokedBy       uint64     `gorm:"type:bigint unsigned" json:"revoked_by"`
+	RevokedAt       *time.Time `gorm:"type:timestamp" json:"revoked_at"`
+	CreatedAt       time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// GroupInviteUse 群聊邀请链接使用记录表
+type GroupInviteUse struct {
+	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	InviteID  uint64    `gorm:"type:bigint unsigned;not null;index" json:"invite_id"`
+	GroupID   uint64    `gorm:"type:bigint unsigned;not null" json:"group_id"`
+	UserID    uint64    `gorm:"type:bigint unsigned;not null;index" json:"user_id"`
+	Result    string    `gorm:"type:enum('joined','requested');not null" json:"result"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
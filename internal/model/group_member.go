@@ -10,6 +10,7 @@ type GroupMember struct {
 	GroupID       uint64     `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
 	UserID        uint64     `gorm:"type:bigint unsigned;not null;index" json:"user_id"`
 	Role          string     `gorm:"type:enum('member','admin','owner');not null;default:'member'" json:"role"`
+	RoleID        uint64     `gorm:"type:bigint unsigned;not null;default:0" json:"role_id"` // 自定义角色ID，0 表示未分配
 	Unread        int        `gorm:"type:int;default:0;not null" json:"unread"`
 	IsMute        bool       `gorm:"type:boolean;default:false" json:"is_mute"`
 	MuteAt        *time.Time `gorm:"type:timestamp" json:"mute_at"`
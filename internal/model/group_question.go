@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// GroupQuestion 群聊入群问题表
+type GroupQuestion struct {
+	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID   uint64    `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
+	Question  string    `gorm:"type:varchar(255);not null" json:"question"`
+	Answer    string    `gorm:"type:varchar(255)" json:"answer,omitempty"` // 预期答案，为空表示自由作答
+	SortOrder int       `gorm:"type:int;not null;default:0" json:"sort_order"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
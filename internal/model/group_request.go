@@ -10,9 +10,13 @@ type GroupRequest struct {
 	UserID         uint64     `gorm:"type:bigint unsigned;not null;index" json:"user_id"`
 	GroupID        uint64     `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
 	Message        string     `gorm:"type:text" json:"message"`
-	Status         string     `gorm:"type:enum('pending','accepted','rejected');not null;default:'pending'" json:"status"`
+	InviteID       uint64     `gorm:"type:bigint unsigned" json:"invite_id"`
+	InviterID      uint64     `gorm:"type:bigint unsigned" json:"inviter_id"`
+	Answers        string     `gorm:"type:text" json:"answers"` // 入群问题的回答（JSON格式）
+	Status         string     `gorm:"type:enum('pending','accepted','rejected','expired');not null;default:'pending'" json:"status"`
 	HandledBy      uint64     `gorm:"type:bigint unsigned" json:"handled_by"`
 	HandledMessage string     `gorm:"type:text" json:"handled_message"`
 	CreatedAt      time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	ExpiresAt      *time.Time `gorm:"type:timestamp;index" json:"expires_at"` // 待处理申请的过期时间，为空表示不过期
 	HandledAt      *time.Time `gorm:"type:timestamp" json:"handled_at"`
 }
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// GroupRole 群聊自定义角色表
+type GroupRole struct {
+	ID          uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID     uint64    `gorm:"type:bigint unsigned;not null;index" json:"group_id"`
+	Name        string    `gorm:"type:varchar(50);not null" json:"name"`
+	Permissions string    `gorm:"type:json" json:"permissions"` // 权限列表（JSON格式）
+	CreatedBy   uint64    `gorm:"type:bigint unsigned;not null" json:"created_by"`
+	CreatedAt   time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
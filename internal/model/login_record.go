@@ -6,9 +6,12 @@ import (
 
 // LoginRecord 登录日志表
 type LoginRecord struct {
-	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
-	UserID    uint64    `gorm:"type:bigint unsigned;not null;index" json:"user_id"`
-	IP        string    `gorm:"type:varchar(50);not null" json:"ip"`
-	UserAgent string    `gorm:"type:varchar(255);not null" json:"user_agent"`
-	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	ID         uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	UserID     uint64    `gorm:"type:bigint unsigned;not null;index" json:"user_id"` // 账号不存在时为0
+	Identifier string    `gorm:"type:varchar(255);not null;default:''" json:"identifier"`
+	IP         string    `gorm:"type:varchar(50);not null;index" json:"ip"`
+	UserAgent  string    `gorm:"type:varchar(255);not null" json:"user_agent"`
+	Status     string    `gorm:"type:varchar(20);not null;default:'success';index" json:"status"` // success、failed 或 blocked
+	Reason     string    `gorm:"type:varchar(100);not null;default:''" json:"reason"`
+	CreatedAt  time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 }
@@ -10,10 +10,13 @@ type Message struct {
 	SenderID     uint64    `gorm:"type:bigint unsigned;not null;index" json:"sender_id"`
 	ReceiverType string    `gorm:"type:enum('user','group');not null" json:"receiver_type"`
 	ReceiverID   uint64    `gorm:"type:bigint unsigned;not null" json:"receiver_id"`
-	MessageType  string    `gorm:"type:enum('text','image','audio','video','file');not null;default:'text'" json:"message_type"`
+	ChannelID    uint64    `gorm:"type:bigint unsigned;not null;default:0;index" json:"channel_id"` // 群聊频道ID，0 表示默认频道
+	MessageType  string    `gorm:"type:enum('text','image','audio','video','file','sticker');not null;default:'text'" json:"message_type"`
 	Content      string    `gorm:"type:text" json:"content"`
 	FileURL      string    `gorm:"type:varchar(255)" json:"file_url"`
 	FileName     string    `gorm:"type:varchar(255)" json:"file_name"`
 	FileSize     int64     `gorm:"type:bigint" json:"file_size"`
+	StickerID    uint64    `gorm:"type:bigint unsigned" json:"sticker_id"`
+	LinkPreviews string    `gorm:"type:text" json:"link_previews"`
 	SentAt       time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"sent_at"`
 }
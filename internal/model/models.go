@@ -12,6 +12,29 @@ type Models struct {
 	BannedWord    BannedWord
 	WebAuthn      WebAuthn
 	LoginRecord   LoginRecord
+
+	StickerPack      StickerPack
+	Sticker          Sticker
+	GroupStickerPack GroupStickerPack
+	Draft            Draft
+
+	ConversationSetting  ConversationSetting
+	ContactGroup         ContactGroup
+	ContactGroupMember   ContactGroupMember
+	GroupInvite          GroupInvite
+	GroupInviteUse       GroupInviteUse
+	GroupInvitation      GroupInvitation
+	GroupBan             GroupBan
+	GroupRole            GroupRole
+	GroupQuestion        GroupQuestion
+	GroupChannel         GroupChannel
+	GroupChannelMember   GroupChannelMember
+	GroupChannelRead     GroupChannelRead
+	GroupAnnouncement    GroupAnnouncement
+	GroupAnnouncementAck GroupAnnouncementAck
+	GroupAuditLog        GroupAuditLog
+	UserRecoveryCode     UserRecoveryCode
+	UserSession          UserSession
 }
 
 // NewModels 创建并返回一个包含所有模型的新实例
@@ -27,5 +50,28 @@ func NewModels() *Models {
 		BannedWord:    BannedWord{},
 		WebAuthn:      WebAuthn{},
 		LoginRecord:   LoginRecord{},
+
+		StickerPack:      StickerPack{},
+		Sticker:          Sticker{},
+		GroupStickerPack: GroupStickerPack{},
+		Draft:            Draft{},
+
+		ConversationSetting:  ConversationSetting{},
+		ContactGroup:         ContactGroup{},
+		ContactGroupMember:   ContactGroupMember{},
+		GroupInvite:          GroupInvite{},
+		GroupInviteUse:       GroupInviteUse{},
+		GroupInvitation:      GroupInvitation{},
+		GroupBan:             GroupBan{},
+		GroupRole:            GroupRole{},
+		GroupQuestion:        GroupQuestion{},
+		GroupChannel:         GroupChannel{},
+		GroupChannelMember:   GroupChannelMember{},
+		GroupChannelRead:     GroupChannelRead{},
+		GroupAnnouncement:    GroupAnnouncement{},
+		GroupAnnouncementAck: GroupAnnouncementAck{},
+		GroupAuditLog:        GroupAuditLog{},
+		UserRecoveryCode:     UserRecoveryCode{},
+		UserSession:          UserSession{},
 	}
 }
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+)
+
+// StickerPack 表情包表
+type StickerPack struct {
+	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Type      string    `gorm:"type:enum('sticker','emoji');not null;default:'sticker'" json:"type"`
+	OwnerType string    `gorm:"type:enum('user','group');not null;default:'user'" json:"owner_type"`
+	OwnerID   uint64    `gorm:"type:bigint unsigned;not null;index" json:"owner_id"`
+	CreatedBy uint64    `gorm:"type:bigint unsigned;not null" json:"created_by"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	Stickers  []Sticker `gorm:"foreignKey:PackID;references:ID" json:"stickers,omitempty"`
+}
+
+// Sticker 表情表
+type Sticker struct {
+	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	PackID    uint64    `gorm:"type:bigint unsigned;not null;index" json:"pack_id"`
+	Name      string    `gorm:"type:varchar(50);not null" json:"name"`
+	FileURL   string    `gorm:"type:varchar(255);not null" json:"file_url"`
+	MimeType  string    `gorm:"type:varchar(50);not null" json:"mime_type"`
+	FileSize  int64     `gorm:"type:bigint;not null" json:"file_size"`
+	Width     int       `gorm:"type:int;not null" json:"width"`
+	Height    int       `gorm:"type:int;not null" json:"height"`
+	CreatedBy uint64    `gorm:"type:bigint unsigned;not null" json:"created_by"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// GroupStickerPack 群聊启用的表情包表
+type GroupStickerPack struct {
+	ID        uint64    `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	GroupID   uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_group_pack" json:"group_id"`
+	PackID    uint64    `gorm:"type:bigint unsigned;not null;uniqueIndex:idx_group_pack" json:"pack_id"`
+	EnabledBy uint64    `gorm:"type:bigint unsigned;not null" json:"enabled_by"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
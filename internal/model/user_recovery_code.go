@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// UserRecoveryCode 双因素认证恢复码表，仅保存恢复码的哈希值
+type UserRecoveryCode struct {
+	ID        uint64     `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	UserID    uint64     `gorm:"type:bigint unsigned;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:char(64);not null;index" json:"-"` // 恢复码的 SHA-256 哈希
+	UsedAt    *time.Time `gorm:"type:timestamp" json:"used_at"`         // 使用时间，为空表示未使用
+	CreatedAt time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// UserSession 登录会话表，每次登录创建一个会话，通过刷新令牌续期
+type UserSession struct {
+	ID               uint64     `gorm:"type:bigint unsigned;primaryKey;autoIncrement" json:"id"`
+	UserID           uint64     `gorm:"type:bigint unsigned;not null;index" json:"user_id"`
+	LoginRecordID    uint64     `gorm:"type:bigint unsigned;not null;default:0" json:"login_record_id"` // 创建会话时的登录日志
+	DeviceName       string     `gorm:"type:varchar(100);not null" json:"device_name"`
+	IP               string     `gorm:"type:varchar(50);not null" json:"ip"`           // 最近一次使用的IP
+	UserAgent        string     `gorm:"type:varchar(255);not null" json:"user_agent"`  // 最近一次使用的设备信息
+	RefreshTokenHash string     `gorm:"type:char(64);not null" json:"-"`               // 当前刷新令牌的 SHA-256 哈希
+	ExpiresAt        time.Time  `gorm:"type:timestamp;not null" json:"expires_at"`     // 刷新令牌过期时间
+	LastActiveAt     time.Time  `gorm:"type:timestamp;not null" json:"last_active_at"` // 最近一次刷新时间
+	RevokedAt        *time.Time `gorm:"type:timestamp;index" json:"revoked_at"`        // 注销时间，为空表示有效
+	RevokedReason    string     `gorm:"type:varchar(50)" json:"revoked_reason"`        // logout、revoked、refresh_reused 等
+	CreatedAt        time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"ventichat/internal/model"
 	"ventichat/internal/utils"
@@ -47,10 +48,99 @@ func InitDatabase() error {
 		&model.BannedWord{},
 		&model.WebAuthn{},
 		&model.LoginRecord{},
+		&model.StickerPack{},
+		&model.Sticker{},
+		&model.GroupStickerPack{},
+		&model.Draft{},
+		&model.ConversationSetting{},
+		&model.ContactGroup{},
+		&model.ContactGroupMember{},
+		&model.GroupInvite{},
+		&model.GroupInviteUse{},
+		&model.GroupInvitation{},
+		&model.GroupBan{},
+		&model.GroupRole{},
+		&model.GroupQuestion{},
+		&model.GroupChannel{},
+		&model.GroupChannelMember{},
+		&model.GroupChannelRead{},
+		&model.GroupAnnouncement{},
+		&model.GroupAnnouncementAck{},
+		&model.GroupAuditLog{},
+		&model.UserRecoveryCode{},
+		&model.UserSession{},
 	); err != nil {
 		return fmt.Errorf("自动迁移数据库失败: %v", err)
 	}
 
+	if err := migrateGroupBlockUsers(); err != nil {
+		return fmt.Errorf("迁移群聊黑名单失败: %v", err)
+	}
+
+	if err := migrateGroupAnnouncements(); err != nil {
+		return fmt.Errorf("迁移群公告失败: %v", err)
+	}
+
+	return nil
+}
+
+// migrateGroupBlockUsers 将旧版 groups.block_users 字段中的黑名单迁移到 group_bans 表
+func migrateGroupBlockUsers() error {
+	if !DB.Migrator().HasColumn(&model.Group{}, "block_users") {
+		return nil
+	}
+
+	var rows []struct {
+		ID         uint64
+		OwnerID    uint64
+		BlockUsers *string
+	}
+	if err := DB.Table("groups").Select("id, owner_id, block_users").Find(&rows).Error; err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			if row.BlockUsers == nil || *row.BlockUsers == "" {
+				continue
+			}
+			var userIDs []uint64
+			if err := json.Unmarshal([]byte(*row.BlockUsers), &userIDs); err != nil {
+				utils.Errorf("解析群聊 %d 的黑名单失败: %v", row.ID, err)
+				continue
+			}
+			for _, userID := range userIDs {
+				ban := model.GroupBan{GroupID: row.ID, UserID: userID, BannedBy: row.OwnerID}
+				if err := tx.Where("group_id = ? AND user_id = ?", row.ID, userID).FirstOrCreate(&ban).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Migrator().DropColumn(&model.Group{}, "block_users")
+	})
+}
+
+// migrateGroupAnnouncements 为已有群公告但没有公告记录的群聊创建首条公告记录
+func migrateGroupAnnouncements() error {
+	var groups []model.Group
+	err := DB.Select("id, owner_id, announcement, created_at").
+		Where("announcement <> '' AND id NOT IN (?)", DB.Model(&model.GroupAnnouncement{}).Select("group_id")).
+		Find(&groups).Error
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		announcement := model.GroupAnnouncement{
+			GroupID:   group.ID,
+			Content:   group.Announcement,
+			AuthorID:  group.OwnerID,
+			CreatedAt: group.CreatedAt,
+		}
+		if err := DB.Create(&announcement).Error; err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -62,22 +62,48 @@ type Config struct {
 		User     string `yaml:"user"`
 		Password string `yaml:"password"`
 		From     string `yaml:"from"`
+
+		DigestInterval int `yaml:"digestInterval"`
 	} `yaml:"email"`
 
 	JWT struct {
-		Key        string `yaml:"key"`
-		Expiration int    `yaml:"expiration"`
+		Key              string `yaml:"key"`
+		Expiration       int    `yaml:"expiration"`
+		AccessExpiration int    `yaml:"accessExpiration"`
+		Algorithm        string `yaml:"algorithm"`
+		KeyID            string `yaml:"keyId"`
+		Issuer           string `yaml:"issuer"`
+		Audience         string `yaml:"audience"`
 	} `yaml:"jwt"`
 
 	IPBan struct {
-		Number int `yaml:"number"`
-		Time   int `yaml:"time"`
+		Number        int      `yaml:"number"`
+		Time          int      `yaml:"time"`
+		AccountNumber int      `yaml:"accountNumber"`
+		Window        int      `yaml:"window"`
+		Allowlist     []string `yaml:"allowlist"`
 	} `yaml:"ipBan"`
 
 	SendFrequency struct {
 		Time   int `yaml:"time"`
 		Number int `yaml:"number"`
 	} `yaml:"sendFrequency"`
+
+	LinkPreview struct {
+		Enable    bool `yaml:"enable"`
+		Timeout   int  `yaml:"timeout"`
+		MaxSize   int  `yaml:"maxSize"`
+		CacheTime int  `yaml:"cacheTime"`
+	} `yaml:"linkPreview"`
+
+	Group struct {
+		MaxMembers int `yaml:"maxMembers"`
+	} `yaml:"group"`
+
+	WebAuthn struct {
+		RPID    string   `yaml:"rpId"`
+		Origins []string `yaml:"origins"`
+	} `yaml:"webauthn"`
 }
 
 // AdminUser 管理员用户信息
@@ -195,6 +221,7 @@ func handleSetup(c *gin.Context) {
 	config.Email.User = c.PostForm("email_user")
 	config.Email.Password = c.PostForm("email_password")
 	config.Email.From = c.PostForm("email_from")
+	config.Email.DigestInterval = 10
 
 	// 自动生成JWT密钥
 	jwtKey, err := generateJWTKey()
@@ -204,13 +231,30 @@ func handleSetup(c *gin.Context) {
 	}
 	config.JWT.Key = jwtKey
 	config.JWT.Expiration, _ = parseInt(c.PostForm("jwt_expiration"))
+	config.JWT.AccessExpiration = 15
+	config.JWT.Algorithm = "HS256"
+	config.JWT.KeyID = "default"
+	config.JWT.Issuer = "ventichat"
+	config.JWT.Audience = "ventichat"
 
 	config.IPBan.Number, _ = parseInt(c.PostForm("ip_ban_number"))
 	config.IPBan.Time, _ = parseInt(c.PostForm("ip_ban_time"))
+	config.IPBan.AccountNumber = 10
+	config.IPBan.Window = 15
+	config.IPBan.Allowlist = []string{"127.0.0.1/32", "::1/128"}
 
 	config.SendFrequency.Time, _ = parseInt(c.PostForm("send_frequency_time"))
 	config.SendFrequency.Number, _ = parseInt(c.PostForm("send_frequency_number"))
 
+	// 链接预览使用默认配置
+	config.LinkPreview.Enable = true
+	config.LinkPreview.Timeout = 5
+	config.LinkPreview.MaxSize = 512
+	config.LinkPreview.CacheTime = 60
+
+	// 群聊默认最大成员数
+	config.Group.MaxMembers = 500
+
 	// 获取管理员账户信息
 	adminUser := AdminUser{
 		Username: c.PostForm("admin_username"),
@@ -349,6 +393,13 @@ func createDatabase(config Config) error {
 	tables := []interface{}{
 		&model.User{}, &model.Friend{}, &model.FriendRequest{}, &model.Group{}, &model.GroupMember{},
 		&model.GroupRequest{}, &model.Message{}, &model.BannedWord{}, &model.WebAuthn{}, &model.LoginRecord{},
+		&model.StickerPack{}, &model.Sticker{}, &model.GroupStickerPack{}, &model.Draft{},
+		&model.ConversationSetting{}, &model.ContactGroup{}, &model.ContactGroupMember{},
+		&model.GroupInvite{}, &model.GroupInviteUse{}, &model.GroupInvitation{},
+		&model.GroupBan{}, &model.GroupRole{}, &model.GroupQuestion{},
+		&model.GroupChannel{}, &model.GroupChannelMember{}, &model.GroupChannelRead{},
+		&model.GroupAnnouncement{}, &model.GroupAnnouncementAck{}, &model.GroupAuditLog{},
+		&model.UserRecoveryCode{}, &model.UserSession{},
 	}
 
 	for _, table := range tables {
@@ -417,7 +468,6 @@ func createDefaultGroup(config Config, groupName string, adminUserID uint64) err
 		Name:        groupName,
 		OwnerID:     adminUserID,
 		Description: "默认聊天室",
-		BlockUsers:  "[]",
 	}
 
 	result := db.Create(&group)
@@ -0,0 +1,275 @@
+// Package token 负责访问令牌（JWT）的签发与校验
+// 支持 HS256、EdDSA 和 RS256 签名，通过 kid 区分密钥，轮换密钥期间可同时接受多个验证密钥
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"ventichat/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims 访问令牌声明
+type Claims struct {
+	UserID    uint64 `json:"user_id"`
+	Username  string `json:"username"`
+	IsAdmin   bool   `json:"is_admin"`
+	SessionID uint64 `json:"sid"` // 登录会话ID
+	jwt.RegisteredClaims
+}
+
+// verificationKey 验证密钥，每个密钥只接受固定的签名算法
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// Service 令牌服务
+type Service struct {
+	method     jwt.SigningMethod
+	signingKey interface{}
+	keyID      string
+	issuer     string
+	audience   string
+	keys       map[string]verificationKey
+	algorithms []string
+}
+
+var defaultService *Service
+
+// Init 使用全局配置初始化默认令牌服务
+func Init() error {
+	service, err := NewService(utils.AppConfig.JWT)
+	if err != nil {
+		return err
+	}
+	defaultService = service
+	return nil
+}
+
+// Issue 使用默认令牌服务签发访问令牌
+func Issue(claims Claims, ttl time.Duration) (string, error) {
+	return defaultService.Issue(claims, ttl)
+}
+
+// Parse 使用默认令牌服务校验访问令牌
+func Parse(tokenString string) (*Claims, error) {
+	return defaultService.Parse(tokenString)
+}
+
+// NewService 根据配置创建令牌服务
+func NewService(config utils.JWTConfig) (*Service, error) {
+	if config.KeyID == "" {
+		return nil, errors.New("未配置签名密钥ID")
+	}
+
+	method, err := signingMethod(config.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	service := &Service{
+		method:   method,
+		keyID:    config.KeyID,
+		issuer:   config.Issuer,
+		audience: config.Audience,
+		keys:     make(map[string]verificationKey),
+	}
+
+	// 当前签名密钥
+	if method == jwt.SigningMethodHS256 {
+		if config.Key == "" {
+			return nil, errors.New("未配置 HS256 签名密钥")
+		}
+		service.signingKey = []byte(config.Key)
+	} else {
+		if service.signingKey, err = loadPrivateKey(method, config.PrivateKey); err != nil {
+			return nil, err
+		}
+	}
+	verifyKey := service.signingKey
+	if signer, ok := service.signingKey.(crypto.Signer); ok {
+		verifyKey = signer.Public()
+	}
+	service.addKey(config.KeyID, method, verifyKey)
+
+	// 轮换期间仍然接受的旧密钥
+	for _, keyConfig := range config.VerificationKeys {
+		if keyConfig.KeyID == "" {
+			return nil, errors.New("验证密钥缺少密钥ID")
+		}
+		if _, exists := service.keys[keyConfig.KeyID]; exists {
+			return nil, fmt.Errorf("密钥ID重复: %s", keyConfig.KeyID)
+		}
+		keyMethod, err := signingMethod(keyConfig.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		var key interface{}
+		if keyMethod == jwt.SigningMethodHS256 {
+			if keyConfig.Key == "" {
+				return nil, fmt.Errorf("验证密钥 %s 未配置 HS256 密钥", keyConfig.KeyID)
+			}
+			key = []byte(keyConfig.Key)
+		} else if key, err = loadPublicKey(keyMethod, keyConfig.PublicKey); err != nil {
+			return nil, err
+		}
+		service.addKey(keyConfig.KeyID, keyMethod, key)
+	}
+
+	return service, nil
+}
+
+// addKey 添加验证密钥并记录允许的签名算法
+func (s *Service) addKey(keyID string, method jwt.SigningMethod, key interface{}) {
+	s.keys[keyID] = verificationKey{method: method, key: key}
+	for _, alg := range s.algorithms {
+		if alg == method.Alg() {
+			return
+		}
+	}
+	s.algorithms = append(s.algorithms, method.Alg())
+}
+
+// Issue 签发访问令牌，签发者、受众、签发时间和过期时间由服务填写
+func (s *Service) Issue(claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    s.issuer,
+		Subject:   strconv.FormatUint(claims.UserID, 10),
+		Audience:  jwt.ClaimStrings{s.audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header["kid"] = s.keyID
+	return token.SignedString(s.signingKey)
+}
+
+// Parse 校验访问令牌的签名、算法、签发者、受众和有效期
+func (s *Service) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(s.algorithms),
+		jwt.WithExpirationRequired(),
+	}
+	if s.issuer != "" {
+		options = append(options, jwt.WithIssuer(s.issuer))
+	}
+	if s.audience != "" {
+		options = append(options, jwt.WithAudience(s.audience))
+	}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		key, ok := s.keys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("未知的密钥ID: %q", keyID)
+		}
+		// 固定每个密钥的签名算法，防止算法混淆攻击
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("签名算法不匹配: %s", token.Method.Alg())
+		}
+		return key.key, nil
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// signingMethod 根据配置的算法名称获取签名方法
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch strings.ToUpper(algorithm) {
+	case "", "HS256":
+		return jwt.SigningMethodHS256, nil
+	case "EDDSA", "ED25519":
+		return jwt.SigningMethodEdDSA, nil
+	case "RS256":
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", algorithm)
+	}
+}
+
+// readPEM 读取 PEM 文件中的第一个数据块
+func readPEM(path string) (*pem.Block, error) {
+	if path == "" {
+		return nil, errors.New("未配置密钥文件")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("密钥文件不是有效的 PEM 格式: %s", path)
+	}
+	return block, nil
+}
+
+// loadPrivateKey 加载 EdDSA 或 RS256 私钥，支持 PKCS#8，RSA 私钥也支持 PKCS#1
+func loadPrivateKey(method jwt.SigningMethod, path string) (interface{}, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil && method == jwt.SigningMethodRS256 {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+
+	switch key.(type) {
+	case ed25519.PrivateKey:
+		if method == jwt.SigningMethodEdDSA {
+			return key, nil
+		}
+	case *rsa.PrivateKey:
+		if method == jwt.SigningMethodRS256 {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("私钥类型与签名算法 %s 不匹配", method.Alg())
+}
+
+// loadPublicKey 加载 EdDSA 或 RS256 公钥（PKIX 格式）
+func loadPublicKey(method jwt.SigningMethod, path string) (interface{}, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %v", err)
+	}
+
+	switch key.(type) {
+	case ed25519.PublicKey:
+		if method == jwt.SigningMethodEdDSA {
+			return key, nil
+		}
+	case *rsa.PublicKey:
+		if method == jwt.SigningMethodRS256 {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("公钥类型与签名算法 %s 不匹配", method.Alg())
+}
@@ -0,0 +1,303 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ventichat/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeys 测试用的密钥文件
+type testKeys struct {
+	ed25519Private string
+	ed25519Public  string
+	rsaPrivate     string
+	rsaPublic      string
+	rsaPublicPEM   []byte
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) (string, []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	dir := t.TempDir()
+	keys := &testKeys{}
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(edPrivate)
+	keys.ed25519Private, _ = writePEM(t, dir, "ed25519.pem", "PRIVATE KEY", der)
+	der, _ = x509.MarshalPKIXPublicKey(edPublic)
+	keys.ed25519Public, _ = writePEM(t, dir, "ed25519.pub.pem", "PUBLIC KEY", der)
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.rsaPrivate, _ = writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPrivate))
+	der, _ = x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+	keys.rsaPublic, keys.rsaPublicPEM = writePEM(t, dir, "rsa.pub.pem", "PUBLIC KEY", der)
+
+	return keys
+}
+
+func newTestService(t *testing.T, config utils.JWTConfig) *Service {
+	t.Helper()
+	if config.Issuer == "" {
+		config.Issuer = "ventichat"
+	}
+	if config.Audience == "" {
+		config.Audience = "ventichat-api"
+	}
+	service, err := NewService(config)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return service
+}
+
+// signToken 使用指定算法、密钥和 kid 直接签发令牌，用于构造异常令牌
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, keyID string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = keyID
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenString
+}
+
+func validClaims() Claims {
+	now := time.Now()
+	return Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "ventichat",
+			Audience:  jwt.ClaimStrings{"ventichat-api"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func TestIssueAndParse(t *testing.T) {
+	keys := newTestKeys(t)
+	for name, config := range map[string]utils.JWTConfig{
+		"HS256": {Algorithm: "HS256", KeyID: "hs", Key: "test-secret"},
+		"EdDSA": {Algorithm: "EdDSA", KeyID: "ed", PrivateKey: keys.ed25519Private},
+		"RS256": {Algorithm: "RS256", KeyID: "rs", PrivateKey: keys.rsaPrivate},
+	} {
+		service := newTestService(t, config)
+		tokenString, err := service.Issue(Claims{UserID: 42, Username: "alice", IsAdmin: true, SessionID: 7}, time.Hour)
+		if err != nil {
+			t.Fatalf("%s: Issue: %v", name, err)
+		}
+		claims, err := service.Parse(tokenString)
+		if err != nil {
+			t.Fatalf("%s: Parse: %v", name, err)
+		}
+		if claims.UserID != 42 || claims.Username != "alice" || !claims.IsAdmin || claims.SessionID != 7 {
+			t.Errorf("%s: claims = %+v", name, claims)
+		}
+		if claims.Subject != "42" || claims.Issuer != "ventichat" {
+			t.Errorf("%s: registered claims = %+v", name, claims.RegisteredClaims)
+		}
+	}
+}
+
+func TestParseRejectsAlgorithmMismatch(t *testing.T) {
+	keys := newTestKeys(t)
+	service := newTestService(t, utils.JWTConfig{
+		Algorithm:  "RS256",
+		KeyID:      "rs",
+		PrivateKey: keys.rsaPrivate,
+		VerificationKeys: []utils.JWTKeyConfig{
+			{KeyID: "hs", Algorithm: "HS256", Key: "old-secret"},
+			{KeyID: "ed", Algorithm: "EdDSA", PublicKey: keys.ed25519Public},
+		},
+	})
+
+	// 使用 RS256 公钥作为 HS256 密钥签名，即算法混淆攻击
+	confused := signToken(t, jwt.SigningMethodHS256, keys.rsaPublicPEM, "rs", validClaims())
+	if _, err := service.Parse(confused); err == nil {
+		t.Error("HS256 token signed with the RS256 public key accepted")
+	}
+
+	// HS256 是允许的算法，但不能用于绑定了 RS256 的 kid
+	if _, err := service.Parse(signToken(t, jwt.SigningMethodHS256, []byte("old-secret"), "rs", validClaims())); err == nil {
+		t.Error("HS256 token with RS256 kid accepted")
+	}
+
+	// RS256 签名的令牌不能使用绑定了 HS256 的 kid
+	rsaKey, err := loadPrivateKey(jwt.SigningMethodRS256, keys.rsaPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Parse(signToken(t, jwt.SigningMethodRS256, rsaKey, "hs", validClaims())); err == nil {
+		t.Error("RS256 token with HS256 kid accepted")
+	}
+
+	// 未启用的算法直接拒绝
+	if _, err := service.Parse(signToken(t, jwt.SigningMethodHS384, []byte("old-secret"), "hs", validClaims())); err == nil {
+		t.Error("HS384 token accepted")
+	}
+	unsigned := signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "rs", validClaims())
+	if _, err := service.Parse(unsigned); err == nil {
+		t.Error("unsigned token accepted")
+	}
+}
+
+func TestParseRejectsUnknownKeyID(t *testing.T) {
+	service := newTestService(t, utils.JWTConfig{Algorithm: "HS256", KeyID: "current", Key: "secret"})
+
+	for _, keyID := range []string{"retired", ""} {
+		if _, err := service.Parse(signToken(t, jwt.SigningMethodHS256, []byte("secret"), keyID, validClaims())); err == nil {
+			t.Errorf("token with kid %q accepted", keyID)
+		}
+	}
+}
+
+func TestParseRejectsInvalidClaims(t *testing.T) {
+	service := newTestService(t, utils.JWTConfig{Algorithm: "HS256", KeyID: "hs", Key: "secret"})
+	sign := func(claims Claims) string {
+		return signToken(t, jwt.SigningMethodHS256, []byte("secret"), "hs", claims)
+	}
+
+	if _, err := service.Parse(sign(validClaims())); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	wrongIssuer := validClaims()
+	wrongIssuer.Issuer = "other"
+	if _, err := service.Parse(sign(wrongIssuer)); err == nil {
+		t.Error("token with wrong issuer accepted")
+	}
+
+	wrongAudience := validClaims()
+	wrongAudience.Audience = jwt.ClaimStrings{"other-api"}
+	if _, err := service.Parse(sign(wrongAudience)); err == nil {
+		t.Error("token with wrong audience accepted")
+	}
+
+	missingExpiration := validClaims()
+	missingExpiration.ExpiresAt = nil
+	if _, err := service.Parse(sign(missingExpiration)); err == nil {
+		t.Error("token without exp accepted")
+	}
+
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	if _, err := service.Parse(sign(expired)); err == nil {
+		t.Error("expired token accepted")
+	}
+
+	// 其他服务签发的令牌即使密钥相同也不能通过签发者校验
+	other := newTestService(t, utils.JWTConfig{Algorithm: "HS256", KeyID: "hs", Key: "secret", Issuer: "other"})
+	tokenString, err := other.Issue(Claims{UserID: 1}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Parse(tokenString); err == nil {
+		t.Error("token issued by another issuer accepted")
+	}
+}
+
+func TestParseAcceptsRotatedKey(t *testing.T) {
+	keys := newTestKeys(t)
+
+	// 轮换前使用 HS256 和 EdDSA 签发的令牌
+	oldHS := newTestService(t, utils.JWTConfig{Algorithm: "HS256", KeyID: "2024-hs", Key: "old-secret"})
+	oldED := newTestService(t, utils.JWTConfig{Algorithm: "EdDSA", KeyID: "2025-ed", PrivateKey: keys.ed25519Private})
+	hsToken, err := oldHS.Issue(Claims{UserID: 1}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edToken, err := oldED.Issue(Claims{UserID: 2}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := newTestService(t, utils.JWTConfig{
+		Algorithm:  "RS256",
+		KeyID:      "2026-rs",
+		PrivateKey: keys.rsaPrivate,
+		VerificationKeys: []utils.JWTKeyConfig{
+			{KeyID: "2024-hs", Algorithm: "HS256", Key: "old-secret"},
+			{KeyID: "2025-ed", Algorithm: "EdDSA", PublicKey: keys.ed25519Public},
+		},
+	})
+
+	if claims, err := current.Parse(hsToken); err != nil || claims.UserID != 1 {
+		t.Errorf("token signed with rotated HS256 key: claims = %v, err = %v", claims, err)
+	}
+	if claims, err := current.Parse(edToken); err != nil || claims.UserID != 2 {
+		t.Errorf("token signed with rotated EdDSA key: claims = %v, err = %v", claims, err)
+	}
+
+	// 轮换后的服务使用新密钥签发
+	tokenString, err := current.Issue(Claims{UserID: 3}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oldHS.Parse(tokenString); err == nil {
+		t.Error("old service accepted token signed with the new key")
+	}
+	if claims, err := current.Parse(tokenString); err != nil || claims.UserID != 3 {
+		t.Errorf("token signed with current key: claims = %v, err = %v", claims, err)
+	}
+
+	// 从验证密钥中移除后不再接受
+	retired := newTestService(t, utils.JWTConfig{Algorithm: "RS256", KeyID: "2026-rs", PrivateKey: keys.rsaPrivate})
+	if _, err := retired.Parse(hsToken); err == nil {
+		t.Error("token signed with removed key accepted")
+	}
+
+	// 再次轮换到 EdDSA 后，RS256 密钥只需配置公钥即可继续验证
+	next := newTestService(t, utils.JWTConfig{
+		Algorithm:  "EdDSA",
+		KeyID:      "2027-ed",
+		PrivateKey: keys.ed25519Private,
+		VerificationKeys: []utils.JWTKeyConfig{
+			{KeyID: "2026-rs", Algorithm: "RS256", PublicKey: keys.rsaPublic},
+		},
+	})
+	if claims, err := next.Parse(tokenString); err != nil || claims.UserID != 3 {
+		t.Errorf("token signed with rotated RS256 key: claims = %v, err = %v", claims, err)
+	}
+}
+
+func TestNewServiceRejectsInvalidConfig(t *testing.T) {
+	keys := newTestKeys(t)
+	for name, config := range map[string]utils.JWTConfig{
+		"missing kid":         {Algorithm: "HS256", Key: "secret"},
+		"missing HS256 key":   {Algorithm: "HS256", KeyID: "hs"},
+		"unknown algorithm":   {Algorithm: "ES256", KeyID: "es", Key: "secret"},
+		"key type mismatch":   {Algorithm: "EdDSA", KeyID: "ed", PrivateKey: keys.rsaPrivate},
+		"duplicate kid":       {Algorithm: "HS256", KeyID: "hs", Key: "secret", VerificationKeys: []utils.JWTKeyConfig{{KeyID: "hs", Algorithm: "HS256", Key: "old"}}},
+		"public key mismatch": {Algorithm: "HS256", KeyID: "hs", Key: "secret", VerificationKeys: []utils.JWTKeyConfig{{KeyID: "rs", Algorithm: "RS256", PublicKey: keys.ed25519Public}}},
+	} {
+		if _, err := NewService(config); err == nil {
+			t.Errorf("%s: NewService succeeded", name)
+		}
+	}
+}
@@ -66,18 +66,39 @@ type EmailConfig struct {
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
 	From     string `mapstructure:"from"`
+
+	DigestInterval int `mapstructure:"digestInterval"` // 未读消息摘要邮件发送间隔（分钟）
 }
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Key        string `mapstructure:"key"`
-	Expiration int    `mapstructure:"expiration"`
+	Key              string `mapstructure:"key"`              // HS256 签名密钥
+	Expiration       int    `mapstructure:"expiration"`       // 登录会话（刷新令牌）有效期（小时）
+	AccessExpiration int    `mapstructure:"accessExpiration"` // 访问令牌有效期（分钟）
+
+	Algorithm        string         `mapstructure:"algorithm"`        // 签名算法：HS256、EdDSA 或 RS256
+	KeyID            string         `mapstructure:"keyId"`            // 当前签名密钥ID，写入令牌头部的 kid
+	PrivateKey       string         `mapstructure:"privateKey"`       // EdDSA/RS256 私钥文件路径（PEM）
+	Issuer           string         `mapstructure:"issuer"`           // 令牌签发者
+	Audience         string         `mapstructure:"audience"`         // 令牌受众
+	VerificationKeys []JWTKeyConfig `mapstructure:"verificationKeys"` // 轮换期间仍然接受的旧密钥
+}
+
+// JWTKeyConfig JWT 验证密钥配置
+type JWTKeyConfig struct {
+	KeyID     string `mapstructure:"keyId"`
+	Algorithm string `mapstructure:"algorithm"` // HS256、EdDSA 或 RS256
+	Key       string `mapstructure:"key"`       // HS256 密钥
+	PublicKey string `mapstructure:"publicKey"` // EdDSA/RS256 公钥文件路径（PEM）
 }
 
 // IPBanConfig IP封禁配置
 type IPBanConfig struct {
-	Number int `mapstructure:"number"`
-	Time   int `mapstructure:"time"`
+	Number        int      `mapstructure:"number"`        // 同一IP登录失败多少次后封禁，0 表示不限制
+	Time          int      `mapstructure:"time"`          // 封禁时长（分钟）
+	AccountNumber int      `mapstructure:"accountNumber"` // 同一账号登录失败多少次后锁定，0 表示不限制
+	Window        int      `mapstructure:"window"`        // 统计登录失败次数的时间窗口（分钟）
+	Allowlist     []string `mapstructure:"allowlist"`     // 不受IP封禁限制的IP或CIDR网段
 }
 
 // SendFrequencyConfig 发送频率配置
@@ -86,6 +107,25 @@ type SendFrequencyConfig struct {
 	Number int `mapstructure:"number"`
 }
 
+// LinkPreviewConfig 链接预览配置
+type LinkPreviewConfig struct {
+	Enable    bool `mapstructure:"enable"`
+	Timeout   int  `mapstructure:"timeout"`   // 抓取超时（秒）
+	MaxSize   int  `mapstructure:"maxSize"`   // 最大读取大小（KB）
+	CacheTime int  `mapstructure:"cacheTime"` // 缓存时间（分钟）
+}
+
+// GroupConfig 群聊配置
+type GroupConfig struct {
+	MaxMembers int `mapstructure:"maxMembers"` // 群聊默认最大成员数
+}
+
+// WebAuthnConfig WebAuthn 配置
+type WebAuthnConfig struct {
+	RPID    string   `mapstructure:"rpId"`    // 依赖方ID（站点域名），为空时使用请求的域名
+	Origins []string `mapstructure:"origins"` // 允许的来源，为空时允许与依赖方ID同域名的 https 来源
+}
+
 // Config 全局配置
 type Config struct {
 	Server        ServerConfig        `mapstructure:"server"`
@@ -95,6 +135,9 @@ type Config struct {
 	JWT           JWTConfig           `mapstructure:"jwt"`
 	IPBan         IPBanConfig         `mapstructure:"ipBan"`
 	SendFrequency SendFrequencyConfig `mapstructure:"sendFrequency"`
+	LinkPreview   LinkPreviewConfig   `mapstructure:"linkPreview"`
+	Group         GroupConfig         `mapstructure:"group"`
+	WebAuthn      WebAuthnConfig      `mapstructure:"webauthn"`
 }
 
 var AppConfig *Config
@@ -105,6 +148,23 @@ func InitConfig() {
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath("./config")
 
+	// 旧版本配置文件中不存在的配置项使用默认值
+	viper.SetDefault("email.digestInterval", 10)
+	viper.SetDefault("linkPreview.enable", true)
+	viper.SetDefault("linkPreview.timeout", 5)
+	viper.SetDefault("linkPreview.maxSize", 512)
+	viper.SetDefault("linkPreview.cacheTime", 60)
+	viper.SetDefault("group.maxMembers", 500)
+	viper.SetDefault("jwt.accessExpiration", 15)
+	viper.SetDefault("jwt.algorithm", "HS256")
+	viper.SetDefault("jwt.keyId", "default")
+	viper.SetDefault("jwt.issuer", "ventichat")
+	viper.SetDefault("jwt.audience", "ventichat")
+	viper.SetDefault("ipBan.number", 5)
+	viper.SetDefault("ipBan.time", 60)
+	viper.SetDefault("ipBan.accountNumber", 10)
+	viper.SetDefault("ipBan.window", 15)
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("读取配置文件失败: %v", err)
 	}
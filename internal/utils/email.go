@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"html"
+	"strings"
 
 	"gopkg.in/gomail.v2"
 )
@@ -49,7 +51,75 @@ func SendVerificationEmail(email, token, username string) {
 
 	m.SetBody("text/html", body)
 
-	// 创建邮件客户端
+	// 发送邮件
+	if err := newEmailDialer().DialAndSend(m); err != nil {
+		Errorf("发送验证邮件失败: %v", err)
+	}
+}
+
+// SendLoginCodeEmail 发送登录验证码邮件
+func SendLoginCodeEmail(email, code, username string, validMinutes int) {
+	m := gomail.NewMessage()
+	m.SetHeader("From", AppConfig.Email.From)
+	m.SetHeader("To", email)
+	m.SetHeader("Subject", "VentiChat登录验证码")
+
+	body := fmt.Sprintf(`
+		<h2>VentiChat登录验证码</h2>
+		<p>您好 %s，</p>
+		<p>您正在使用邮箱验证码登录VentiChat，验证码为：</p>
+		<p style="font-size: 24px; font-weight: bold; letter-spacing: 4px;">%s</p>
+		<p>验证码将在%d分钟内有效。如非本人操作，请忽略此邮件并及时修改密码。</p>
+		<hr>
+		<p><em>此邮件由系统自动发送，请勿回复。</em></p>
+	`, html.EscapeString(username), code, validMinutes)
+
+	m.SetBody("text/html", body)
+
+	if err := newEmailDialer().DialAndSend(m); err != nil {
+		Errorf("发送登录验证码邮件失败: %v", err)
+	}
+}
+
+// DigestItem 消息摘要邮件中的会话未读信息
+type DigestItem struct {
+	Name  string
+	Count int64
+}
+
+// SendMessageDigestEmail 发送未读消息摘要邮件
+func SendMessageDigestEmail(email, username string, items []DigestItem) {
+	m := gomail.NewMessage()
+	m.SetHeader("From", AppConfig.Email.From)
+	m.SetHeader("To", email)
+	m.SetHeader("Subject", "VentiChat未读消息提醒")
+
+	var list strings.Builder
+	var total int64
+	for _, item := range items {
+		total += item.Count
+		list.WriteString(fmt.Sprintf("<li>%s：%d 条新消息</li>", html.EscapeString(item.Name), item.Count))
+	}
+
+	body := fmt.Sprintf(`
+		<h2>VentiChat未读消息提醒</h2>
+		<p>您好 %s，</p>
+		<p>您离线期间共收到 %d 条新消息：</p>
+		<ul>%s</ul>
+		<p><a href="%s" target="_blank">点击查看消息</a></p>
+		<hr>
+		<p><em>此邮件由系统自动发送，请勿回复。如不希望收到此类邮件，可在设置中关闭邮件通知或对会话开启免打扰。</em></p>
+	`, html.EscapeString(username), total, list.String(), AppConfig.Server.Host)
+
+	m.SetBody("text/html", body)
+
+	if err := newEmailDialer().DialAndSend(m); err != nil {
+		Errorf("发送消息摘要邮件失败: %v", err)
+	}
+}
+
+// newEmailDialer 根据配置创建邮件客户端
+func newEmailDialer() *gomail.Dialer {
 	dialer := gomail.NewDialer(
 		AppConfig.Email.Host,
 		AppConfig.Email.Port,
@@ -62,8 +132,5 @@ func SendVerificationEmail(email, token, username string) {
 		dialer.SSL = true
 	}
 
-	// 发送邮件
-	if err := dialer.DialAndSend(m); err != nil {
-		Errorf("发送验证邮件失败: %v", err)
-	}
+	return dialer
 }
\ No newline at end of file
@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// LinkPreview 链接预览信息
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+	SiteName    string `json:"site_name"`
+}
+
+// ErrForbiddenAddress 目标地址属于内网或保留地址
+var ErrForbiddenAddress = errors.New("禁止访问内网或保留地址")
+
+// urlPattern 匹配消息中的http/https链接
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'，。！？、；：（）【】]+`)
+
+// reservedNetworks 除标准库判断外需要额外拦截的保留网段
+var reservedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // 本网络
+	"100.64.0.0/10",   // 运营商级NAT
+	"192.0.0.0/24",    // IETF协议分配
+	"192.0.2.0/24",    // TEST-NET-1
+	"198.18.0.0/15",   // 基准测试
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"240.0.0.0/4",     // 保留地址
+	"64:ff9b::/96",    // NAT64
+	"2001:db8::/32",   // 文档地址
+)
+
+// mustParseCIDRs 解析CIDR列表，解析失败时panic
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("无效的CIDR %s: %v", cidr, err))
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// IsPublicIP 判断IP是否为可以对外访问的公网地址
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExtractURLs 提取消息中的链接（去重，最多limit个）
+func ExtractURLs(content string, limit int) []string {
+	matches := urlPattern.FindAllString(content, -1)
+	seen := make(map[string]bool)
+	var urls []string
+	for _, match := range matches {
+		match = strings.TrimRight(match, ".,!?;:)]}")
+		if seen[match] {
+			continue
+		}
+		seen[match] = true
+		urls = append(urls, match)
+		if limit > 0 && len(urls) >= limit {
+			break
+		}
+	}
+	return urls
+}
+
+// LinkPreviewFetcher 链接预览抓取器
+// 所有连接在拨号阶段校验实际解析出的IP，防止通过DNS重绑定或跳转访问内网
+type LinkPreviewFetcher struct {
+	client   *http.Client
+	maxBytes int64
+	allowIP  func(ip net.IP) bool
+}
+
+// maxLinkPreviewRedirects 抓取链接预览时允许的最大重定向次数
+const maxLinkPreviewRedirects = 5
+
+// LinkPreviewOption 链接预览抓取器选项
+type LinkPreviewOption func(*LinkPreviewFetcher)
+
+// WithIPFilter 替换拨号阶段的IP校验函数，默认只允许公网地址
+func WithIPFilter(allowIP func(ip net.IP) bool) LinkPreviewOption {
+	return func(f *LinkPreviewFetcher) {
+		f.allowIP = allowIP
+	}
+}
+
+// NewLinkPreviewFetcher 创建链接预览抓取器
+func NewLinkPreviewFetcher(timeout time.Duration, maxBytes int64, options ...LinkPreviewOption) *LinkPreviewFetcher {
+	fetcher := &LinkPreviewFetcher{
+		maxBytes: maxBytes,
+		allowIP:  IsPublicIP,
+	}
+	for _, option := range options {
+		option(fetcher)
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !fetcher.allowIP(net.ParseIP(host)) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // 不走代理，确保拨号校验生效
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	fetcher.client = &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxLinkPreviewRedirects {
+				return errors.New("重定向次数过多")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("不支持的重定向协议")
+			}
+			return nil
+		},
+	}
+
+	return fetcher
+}
+
+// Fetch 抓取链接的标题、描述和图片
+func (f *LinkPreviewFetcher) Fetch(ctx context.Context, rawURL string) (*LinkPreview, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("不支持的协议: %s", target.Scheme)
+	}
+	if target.Hostname() == "" {
+		return nil, errors.New("链接缺少主机名")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "VentiChat-LinkPreview/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("请求失败，状态码 %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("不支持的内容类型: %s", mediaType)
+	}
+
+	// 限制读取大小，超出部分直接丢弃
+	body := io.LimitReader(resp.Body, f.maxBytes)
+	preview, err := parseLinkPreview(body, resp.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+	preview.URL = rawURL
+	return preview, nil
+}
+
+// parseLinkPreview 从HTML中解析预览信息
+func parseLinkPreview(r io.Reader, base *url.URL) (*LinkPreview, error) {
+	tokenizer := html.NewTokenizer(r)
+	preview := &LinkPreview{}
+	var title, description string
+	inTitle := false
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != nil && err != io.EOF {
+				return nil, err
+			}
+			return finishLinkPreview(preview, title, description, base), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = tokenType == html.StartTagToken
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				var key, content string
+				for {
+					attrKey, attrVal, more := tokenizer.TagAttr()
+					switch strings.ToLower(string(attrKey)) {
+					case "property", "name":
+						key = strings.ToLower(string(attrVal))
+					case "content":
+						content = strings.TrimSpace(string(attrVal))
+					}
+					if !more {
+						break
+					}
+				}
+				switch key {
+				case "og:title", "twitter:title":
+					if preview.Title == "" {
+						preview.Title = content
+					}
+				case "og:description", "twitter:description":
+					if preview.Description == "" {
+						preview.Description = content
+					}
+				case "description":
+					description = content
+				case "og:image", "og:image:url", "twitter:image":
+					if preview.Image == "" {
+						preview.Image = content
+					}
+				case "og:site_name":
+					preview.SiteName = content
+				}
+			case "body":
+				// 预览信息只存在于head中
+				return finishLinkPreview(preview, title, description, base), nil
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "title" {
+				inTitle = false
+			}
+		}
+	}
+}
+
+// finishLinkPreview 补全缺省字段并处理相对图片地址
+func finishLinkPreview(preview *LinkPreview, title, description string, base *url.URL) *LinkPreview {
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Description == "" {
+		preview.Description = description
+	}
+	if preview.Image != "" && base != nil {
+		if imageURL, err := base.Parse(preview.Image); err == nil &&
+			(imageURL.Scheme == "http" || imageURL.Scheme == "https") {
+			preview.Image = imageURL.String()
+		} else {
+			preview.Image = ""
+		}
+	}
+	if preview.SiteName == "" && base != nil {
+		preview.SiteName = base.Hostname()
+	}
+	return preview
+}
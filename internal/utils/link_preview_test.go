@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// allowLoopback 测试用的IP校验：允许本地 httptest 服务，其余地址仍按公网规则校验
+func allowLoopback(ip net.IP) bool {
+	return ip.IsLoopback() || IsPublicIP(ip)
+}
+
+func newTestFetcher(options ...LinkPreviewOption) *LinkPreviewFetcher {
+	return NewLinkPreviewFetcher(2*time.Second, 64*1024, options...)
+}
+
+func serveHTML(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	cases := map[string]bool{
+		"8.8.8.8":          true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.0.0.1":         false,
+		"172.16.5.4":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false, // 云服务器元数据地址
+		"fd00:ec2::254":    false,
+		"fe80::1":          false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::ffff:127.0.0.1": false,
+		"::ffff:10.0.0.1":  false,
+	}
+	for address, want := range cases {
+		if got := IsPublicIP(net.ParseIP(address)); got != want {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", address, got, want)
+		}
+	}
+	if IsPublicIP(nil) {
+		t.Error("IsPublicIP(nil) = true, want false")
+	}
+}
+
+func TestFetchBlocksLoopbackByDefault(t *testing.T) {
+	server := httptest.NewServer(serveHTML("<title>secret</title>"))
+	defer server.Close()
+
+	_, err := newTestFetcher().Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Fetch(loopback) error = %v, want ErrForbiddenAddress", err)
+	}
+}
+
+func TestFetchBlocksPrivateAddressAfterRedirect(t *testing.T) {
+	for _, target := range []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://[fd00::1]/",
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, target, http.StatusFound)
+		}))
+
+		_, err := newTestFetcher(WithIPFilter(allowLoopback)).Fetch(context.Background(), server.URL)
+		server.Close()
+		if !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("redirect to %s: error = %v, want ErrForbiddenAddress", target, err)
+		}
+	}
+}
+
+func TestFetchRedirectLimit(t *testing.T) {
+	// /redirect/N 再重定向 N 次后返回页面
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/redirect/"))
+		if remaining > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/redirect/%d", remaining-1), http.StatusFound)
+			return
+		}
+		serveHTML("<title>done</title>")(w, r)
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher(WithIPFilter(allowLoopback))
+
+	preview, err := fetcher.Fetch(context.Background(), fmt.Sprintf("%s/redirect/%d", server.URL, maxLinkPreviewRedirects))
+	if err != nil {
+		t.Fatalf("%d redirects: unexpected error %v", maxLinkPreviewRedirects, err)
+	}
+	if preview.Title != "done" {
+		t.Errorf("title = %q, want %q", preview.Title, "done")
+	}
+
+	_, err = fetcher.Fetch(context.Background(), fmt.Sprintf("%s/redirect/%d", server.URL, maxLinkPreviewRedirects+1))
+	if err == nil || !strings.Contains(err.Error(), "重定向次数过多") {
+		t.Fatalf("%d redirects: error = %v, want redirect limit error", maxLinkPreviewRedirects+1, err)
+	}
+}
+
+func TestFetchBodySizeLimit(t *testing.T) {
+	// 标题位于读取上限之后，不应被解析
+	padding := strings.Repeat("<!-- padding -->", 1024)
+	server := httptest.NewServer(serveHTML("<html><head>" + padding + "<title>too far</title></head></html>"))
+	defer server.Close()
+
+	fetcher := NewLinkPreviewFetcher(2*time.Second, 1024, WithIPFilter(allowLoopback))
+	preview, err := fetcher.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if preview.Title != "" {
+		t.Errorf("title = %q, want empty because it is beyond the size limit", preview.Title)
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+		serveHTML("<title>slow</title>")(w, r)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	fetcher := NewLinkPreviewFetcher(200*time.Millisecond, 64*1024, WithIPFilter(allowLoopback))
+	start := time.Now()
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("fetch took %v, want it to stop near the timeout", elapsed)
+	}
+}
+
+func TestFetchRejectsNonHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"title":"json"}`)
+	}))
+	defer server.Close()
+
+	if _, err := newTestFetcher(WithIPFilter(allowLoopback)).Fetch(context.Background(), server.URL); err == nil {
+		t.Fatal("expected error for non-HTML content")
+	}
+}
+
+func TestFetchParsesOpenGraph(t *testing.T) {
+	server := httptest.NewServer(serveHTML(`<html><head>
+<title>Fallback title</title>
+<meta property="og:title" content=" OG title ">
+<meta property="og:description" content="OG description">
+<meta property="og:image" content="/images/cover.png">
+<meta property="og:site_name" content="Example">
+</head><body><meta property="og:title" content="ignored"></body></html>`))
+	defer server.Close()
+
+	preview, err := newTestFetcher(WithIPFilter(allowLoopback)).Fetch(context.Background(), server.URL+"/article")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	want := LinkPreview{
+		URL:         server.URL + "/article",
+		Title:       "OG title",
+		Description: "OG description",
+		Image:       server.URL + "/images/cover.png",
+		SiteName:    "Example",
+	}
+	if *preview != want {
+		t.Errorf("preview = %+v, want %+v", *preview, want)
+	}
+}
+
+func TestParseLinkPreviewFallbacks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/post")
+	preview, err := parseLinkPreview(strings.NewReader(`<html><head>
+<title> Page title </title>
+<meta name="description" content="Plain description">
+<meta name="twitter:image" content="javascript:alert(1)">
+</head></html>`), base)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if preview.Title != "Page title" {
+		t.Errorf("title = %q, want %q", preview.Title, "Page title")
+	}
+	if preview.Description != "Plain description" {
+		t.Errorf("description = %q, want %q", preview.Description, "Plain description")
+	}
+	if preview.Image != "" {
+		t.Errorf("image = %q, want non-http image dropped", preview.Image)
+	}
+	if preview.SiteName != "example.com" {
+		t.Errorf("site name = %q, want %q", preview.SiteName, "example.com")
+	}
+}
+
+func TestExtractURLs(t *testing.T) {
+	got := ExtractURLs("看看 https://example.com/a, 还有 http://example.org/b。以及 https://example.com/a", 5)
+	want := []string{"https://example.com/a", "http://example.org/b"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("ExtractURLs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 使用的密钥
+var (
+	rfc6238SHA1Key   = []byte("12345678901234567890")
+	rfc6238SHA256Key = []byte("12345678901234567890123456789012")
+	rfc6238SHA512Key = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+)
+
+func TestHOTPCodeRFC4226(t *testing.T) {
+	// RFC 4226 附录 D
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, code := range want {
+		if got := HOTPCode(rfc6238SHA1Key, uint64(counter), 6, sha1.New); got != code {
+			t.Errorf("HOTPCode(counter=%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	cases := []struct {
+		unix   int64
+		sha1   string
+		sha256 string
+		sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+	for _, tc := range cases {
+		for _, alg := range []struct {
+			name string
+			key  []byte
+			fn   func() hash.Hash
+			want string
+		}{
+			{"SHA1", rfc6238SHA1Key, sha1.New, tc.sha1},
+			{"SHA256", rfc6238SHA256Key, sha256.New, tc.sha256},
+			{"SHA512", rfc6238SHA512Key, sha512.New, tc.sha512},
+		} {
+			got := TOTPCode(alg.key, time.Unix(tc.unix, 0), 30, 8, alg.fn)
+			if got != alg.want {
+				t.Errorf("TOTPCode(%s, T=%d) = %s, want %s", alg.name, tc.unix, got, alg.want)
+			}
+		}
+	}
+}
+
+func TestValidateTOTPDriftWindow(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfc6238SHA1Key)
+	now := time.Unix(1111111111, 0)
+	counter := TOTPCounter(now, TOTPPeriod)
+
+	codeAt := func(offset int) string {
+		return TOTPCode(rfc6238SHA1Key, now.Add(time.Duration(offset)*TOTPPeriod*time.Second), TOTPPeriod, TOTPDigits, sha1.New)
+	}
+
+	for _, offset := range []int{-1, 0, 1} {
+		step, ok := ValidateTOTP(secret, codeAt(offset), now)
+		if !ok {
+			t.Errorf("code from step offset %d rejected", offset)
+			continue
+		}
+		if want := counter + uint64(offset); step != want {
+			t.Errorf("offset %d: matched step %d, want %d", offset, step, want)
+		}
+	}
+
+	for _, offset := range []int{-2, 2} {
+		code := codeAt(offset)
+		// 相邻时间步的验证码可能碰巧相同，此时跳过
+		if code == codeAt(-1) || code == codeAt(0) || code == codeAt(1) {
+			continue
+		}
+		if _, ok := ValidateTOTP(secret, code, now); ok {
+			t.Errorf("code from step offset %d accepted", offset)
+		}
+	}
+}
+
+func TestValidateTOTPInput(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfc6238SHA1Key)
+	now := time.Unix(1234567890, 0)
+	code := TOTPCode(rfc6238SHA1Key, now, TOTPPeriod, TOTPDigits, sha1.New)
+
+	// 密钥允许小写和空格，验证码允许首尾空白
+	loose := strings.ToLower(secret[:4] + " " + secret[4:])
+	if _, ok := ValidateTOTP(loose, " "+code+" ", now); !ok {
+		t.Error("valid code with formatted secret rejected")
+	}
+
+	for _, bad := range []string{"", code[:5], code + "0", "abcdef"} {
+		if _, ok := ValidateTOTP(secret, bad, now); ok {
+			t.Errorf("ValidateTOTP(%q) accepted", bad)
+		}
+	}
+	if _, ok := ValidateTOTP("!!!", code, now); ok {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecodeTOTPSecret(secret)
+	if err != nil {
+		t.Fatalf("generated secret does not decode: %v", err)
+	}
+	if len(key) != 20 {
+		t.Errorf("key length = %d, want 20", len(key))
+	}
+}
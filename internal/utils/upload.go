@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// UploadDir 上传文件的本地存储目录，通过 /uploads 对外提供访问
+const UploadDir = "./uploads"
+
+// 允许上传的图片类型及对应扩展名
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// ImageInfo 图片校验结果
+type ImageInfo struct {
+	MimeType  string
+	Extension string
+	Width     int
+	Height    int
+}
+
+// ValidateImage 校验图片内容、大小和尺寸
+// 图片类型根据文件内容判断，不信任客户端提供的Content-Type
+func ValidateImage(data []byte, maxSize int64, maxDimension int) (*ImageInfo, error) {
+	if len(data) == 0 {
+		return nil, errors.New("文件为空")
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("文件大小不能超过%dKB", maxSize/1024)
+	}
+
+	mimeType := http.DetectContentType(data)
+	ext, ok := imageExtensions[mimeType]
+	if !ok {
+		return nil, errors.New("仅支持PNG、JPEG和GIF格式的图片")
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("图片文件已损坏")
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return nil, fmt.Errorf("图片尺寸不能超过%dx%d", maxDimension, maxDimension)
+	}
+
+	return &ImageInfo{
+		MimeType:  mimeType,
+		Extension: ext,
+		Width:     config.Width,
+		Height:    config.Height,
+	}, nil
+}
+
+// SaveUploadFile 将文件保存到上传目录的子目录中，返回可访问的URL
+func SaveUploadFile(subDir, ext string, data []byte) (string, error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	filename := hex.EncodeToString(randomBytes) + ext
+
+	dir := filepath.Join(UploadDir, filepath.FromSlash(subDir))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, filename), data, 0644); err != nil {
+		return "", err
+	}
+
+	return path.Join("/uploads", subDir, filename), nil
+}
+
+// RemoveUploadFile 根据URL删除上传目录中的文件
+func RemoveUploadFile(fileURL string) {
+	rel, err := filepath.Rel("/uploads", filepath.FromSlash(path.Clean(fileURL)))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+	if err := os.Remove(filepath.Join(UploadDir, rel)); err != nil && !os.IsNotExist(err) {
+		Errorf("删除上传文件失败: %v", err)
+	}
+}
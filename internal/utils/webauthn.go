@@ -0,0 +1,285 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+)
+
+// 认证器数据标志位
+const (
+	webauthnFlagUserPresent  = 0x01
+	webauthnFlagUserVerified = 0x04
+	webauthnFlagAttestedData = 0x40
+)
+
+// COSE 算法标识
+const (
+	COSEAlgES256 = -7
+	COSEAlgEdDSA = -8
+	COSEAlgRS256 = -257
+)
+
+// WebAuthnAlgorithms 支持的公钥算法，按优先级排列
+var WebAuthnAlgorithms = []int{COSEAlgES256, COSEAlgEdDSA, COSEAlgRS256}
+
+// WebAuthnClientData 客户端数据（clientDataJSON）
+type WebAuthnClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// WebAuthnAuthData 认证器数据
+type WebAuthnAuthData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte // 仅注册时存在
+	PublicKey    []byte // 仅注册时存在，COSE 格式
+}
+
+// UserPresent 用户是否在场（触摸了认证器）
+func (a *WebAuthnAuthData) UserPresent() bool {
+	return a.Flags&webauthnFlagUserPresent != 0
+}
+
+// UserVerified 认证器是否验证了用户身份（PIN、指纹等）
+func (a *WebAuthnAuthData) UserVerified() bool {
+	return a.Flags&webauthnFlagUserVerified != 0
+}
+
+// ParseWebAuthnClientData 解析客户端数据并检查类型
+func ParseWebAuthnClientData(raw []byte, expectedType string) (*WebAuthnClientData, error) {
+	var clientData WebAuthnClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, fmt.Errorf("解析客户端数据失败: %v", err)
+	}
+	if clientData.Type != expectedType {
+		return nil, fmt.Errorf("客户端数据类型错误: %s", clientData.Type)
+	}
+	if clientData.CrossOrigin {
+		return nil, errors.New("不支持跨域调用")
+	}
+	return &clientData, nil
+}
+
+// CheckWebAuthnClientData 检查客户端数据中的挑战和来源
+func CheckWebAuthnClientData(clientData *WebAuthnClientData, challenge, rpID string, origins []string) error {
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return errors.New("挑战不匹配")
+	}
+	if !WebAuthnOriginAllowed(clientData.Origin, rpID, origins) {
+		return errors.New("来源不被允许")
+	}
+	return nil
+}
+
+// WebAuthnOriginAllowed 检查来源是否被允许
+// 配置了允许的来源时只接受列表中的来源，否则接受与依赖方ID同域名（含子域名）的 https 来源，localhost 允许 http
+func WebAuthnOriginAllowed(origin, rpID string, origins []string) bool {
+	if len(origins) > 0 {
+		for _, allowed := range origins {
+			if origin == allowed {
+				return true
+			}
+		}
+		return false
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	hostname := parsed.Hostname()
+	if hostname != rpID && !strings.HasSuffix(hostname, "."+rpID) {
+		return false
+	}
+	return parsed.Scheme == "https" || (parsed.Scheme == "http" && hostname == "localhost")
+}
+
+// ParseWebAuthnAuthData 解析认证器数据
+func ParseWebAuthnAuthData(data []byte) (*WebAuthnAuthData, error) {
+	// rpIdHash(32) + flags(1) + signCount(4)
+	if len(data) < 37 {
+		return nil, errors.New("认证器数据长度不足")
+	}
+
+	authData := &WebAuthnAuthData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	if authData.Flags&webauthnFlagAttestedData == 0 {
+		return authData, nil
+	}
+
+	// aaguid(16) + credentialIdLength(2) + credentialId + credentialPublicKey
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("认证器数据长度不足")
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || len(rest) < idLen {
+		return nil, errors.New("凭证ID长度错误")
+	}
+	authData.CredentialID = rest[:idLen]
+	rest = rest[idLen:]
+
+	_, remaining, err := DecodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("解析凭证公钥失败: %v", err)
+	}
+	authData.PublicKey = rest[:len(rest)-len(remaining)]
+	return authData, nil
+}
+
+// ParseWebAuthnAttestation 解析注册时的 attestationObject，返回认证器数据
+// 服务端请求的证明方式为 none，因此不校验证明声明，只使用其中的凭证信息
+func ParseWebAuthnAttestation(attestationObject []byte) (*WebAuthnAuthData, error) {
+	decoded, _, err := DecodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("解析证明对象失败: %v", err)
+	}
+	object, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("证明对象格式错误")
+	}
+	rawAuthData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("证明对象缺少认证器数据")
+	}
+
+	authData, err := ParseWebAuthnAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.CredentialID == nil {
+		return nil, errors.New("证明对象缺少凭证数据")
+	}
+	if _, _, err := ParseCOSEKey(authData.PublicKey); err != nil {
+		return nil, err
+	}
+	return authData, nil
+}
+
+// CheckWebAuthnAuthData 检查认证器数据的依赖方和用户标志
+func CheckWebAuthnAuthData(authData *WebAuthnAuthData, rpID string, requireUserVerified bool) error {
+	expected := sha256.Sum256([]byte(rpID))
+	if !bytes.Equal(authData.RPIDHash, expected[:]) {
+		return errors.New("依赖方ID不匹配")
+	}
+	if !authData.UserPresent() {
+		return errors.New("用户未确认操作")
+	}
+	if requireUserVerified && !authData.UserVerified() {
+		return errors.New("认证器未验证用户身份")
+	}
+	return nil
+}
+
+// CheckWebAuthnSignCount 检查签名计数器是否增长，未增长说明认证器可能被复制
+// 已保存的和收到的计数器均为 0 表示认证器不支持计数器
+func CheckWebAuthnSignCount(stored, received uint32) error {
+	if (stored != 0 || received != 0) && received <= stored {
+		return errors.New("签名计数器未增长")
+	}
+	return nil
+}
+
+// ParseCOSEKey 解析 COSE 格式的公钥
+func ParseCOSEKey(raw []byte) (crypto.PublicKey, int64, error) {
+	decoded, _, err := DecodeCBOR(raw)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析公钥失败: %v", err)
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errors.New("公钥格式错误")
+	}
+
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+	crv, _ := key[int64(-1)].(int64)
+
+	switch {
+	case kty == 2 && alg == COSEAlgES256 && crv == 1:
+		x, okX := key[int64(-2)].([]byte)
+		y, okY := key[int64(-3)].([]byte)
+		if !okX || !okY || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("EC2公钥格式错误")
+		}
+		publicKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, 0, errors.New("EC2公钥不在曲线上")
+		}
+		return publicKey, alg, nil
+	case kty == 1 && alg == COSEAlgEdDSA && crv == 6:
+		x, ok := key[int64(-2)].([]byte)
+		if !ok || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("OKP公钥格式错误")
+		}
+		return ed25519.PublicKey(x), alg, nil
+	case kty == 3 && alg == COSEAlgRS256:
+		n, okN := key[int64(-1)].([]byte)
+		e, okE := key[int64(-2)].([]byte)
+		if !okN || !okE || len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("RSA公钥格式错误")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, alg, nil
+	default:
+		return nil, 0, errors.New("不支持的公钥算法")
+	}
+}
+
+// VerifyWebAuthnSignature 使用 COSE 公钥校验断言签名
+// 签名内容为 authenticatorData 与 clientDataJSON 的 SHA-256 摘要的拼接
+func VerifyWebAuthnSignature(coseKey, authenticatorData, clientDataJSON, signature []byte) error {
+	publicKey, alg, err := ParseCOSEKey(coseKey)
+	if err != nil {
+		return err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := make([]byte, 0, len(authenticatorData)+len(clientDataHash))
+	signed = append(signed, authenticatorData...)
+	signed = append(signed, clientDataHash[:]...)
+
+	var valid bool
+	switch alg {
+	case COSEAlgES256:
+		digest := sha256.Sum256(signed)
+		valid = ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), digest[:], signature)
+	case COSEAlgEdDSA:
+		valid = ed25519.Verify(publicKey.(ed25519.PublicKey), signed, signature)
+	case COSEAlgRS256:
+		digest := sha256.Sum256(signed)
+		valid = rsa.VerifyPKCS1v15(publicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	}
+	if !valid {
+		return errors.New("签名校验失败")
+	}
+	return nil
+}
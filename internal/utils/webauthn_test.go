@@ -0,0 +1,407 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"testing"
+)
+
+const testRPID = "chat.example.com"
+
+// cborHead 编码 CBOR 数据项的头部
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		head := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(head[1:], uint16(arg))
+		return head
+	default:
+		head := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(head[1:], uint32(arg))
+		return head
+	}
+}
+
+// encodeCBOR 测试用的 CBOR 编码，只支持构造 WebAuthn 数据需要的类型
+func encodeCBOR(t *testing.T, value interface{}) []byte {
+	t.Helper()
+	switch v := value.(type) {
+	case int:
+		if v >= 0 {
+			return cborHead(0, uint64(v))
+		}
+		return cborHead(1, uint64(-1-v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case map[interface{}]interface{}:
+		// 按编码后的键排序，保证输出稳定
+		type entry struct{ key, value []byte }
+		entries := make([]entry, 0, len(v))
+		for key, item := range v {
+			entries = append(entries, entry{encodeCBOR(t, key), encodeCBOR(t, item)})
+		}
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+		out := cborHead(5, uint64(len(v)))
+		for _, e := range entries {
+			out = append(out, e.key...)
+			out = append(out, e.value...)
+		}
+		return out
+	default:
+		t.Fatalf("unsupported CBOR test value %T", value)
+		return nil
+	}
+}
+
+// testAuthenticator 软件模拟的 ES256 认证器
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 16)
+	rand.Read(credentialID)
+	return &testAuthenticator{key: key, credentialID: credentialID}
+}
+
+// coseKey 公钥的 COSE 编码
+func (a *testAuthenticator) coseKey(t *testing.T) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	return encodeCBOR(t, map[interface{}]interface{}{
+		1: 2, 3: COSEAlgES256, -1: 1, -2: x, -3: y,
+	})
+}
+
+// authData 构造认证器数据，attested 为 true 时附带凭证数据
+func (a *testAuthenticator) authData(t *testing.T, rpID string, flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	if attested {
+		flags |= webauthnFlagAttestedData
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // aaguid
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey(t)...)
+	}
+	return data
+}
+
+// attestation 构造证明方式为 none 的 attestationObject
+func (a *testAuthenticator) attestation(t *testing.T, rpID string, flags byte) []byte {
+	return encodeCBOR(t, map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": a.authData(t, rpID, flags, true),
+	})
+}
+
+// assert 构造断言：签名内容为 authData 与 clientDataJSON 哈希的拼接
+func (a *testAuthenticator) assert(t *testing.T, rpID string, flags byte, clientDataJSON []byte) ([]byte, []byte) {
+	a.signCount++
+	authData := a.authData(t, rpID, flags, false)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authData, signature
+}
+
+func clientDataJSON(t *testing.T, typ, challenge, origin string) []byte {
+	data, err := json.Marshal(WebAuthnClientData{Type: typ, Challenge: challenge, Origin: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseWebAuthnAttestationNone(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	flags := byte(webauthnFlagUserPresent | webauthnFlagUserVerified)
+
+	authData, err := ParseWebAuthnAttestation(authenticator.attestation(t, testRPID, flags))
+	if err != nil {
+		t.Fatalf("ParseWebAuthnAttestation: %v", err)
+	}
+	if !bytes.Equal(authData.CredentialID, authenticator.credentialID) {
+		t.Error("credential ID mismatch")
+	}
+	if !bytes.Equal(authData.PublicKey, authenticator.coseKey(t)) {
+		t.Error("public key mismatch")
+	}
+	if err := CheckWebAuthnAuthData(authData, testRPID, true); err != nil {
+		t.Errorf("CheckWebAuthnAuthData: %v", err)
+	}
+
+	publicKey, alg, err := ParseCOSEKey(authData.PublicKey)
+	if err != nil {
+		t.Fatalf("ParseCOSEKey: %v", err)
+	}
+	if alg != COSEAlgES256 || !authenticator.key.PublicKey.Equal(publicKey) {
+		t.Error("decoded public key does not match the authenticator key")
+	}
+}
+
+func TestParseWebAuthnAttestationRejectsMissingCredential(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	object := encodeCBOR(t, map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": authenticator.authData(t, testRPID, webauthnFlagUserPresent, false),
+	})
+	if _, err := ParseWebAuthnAttestation(object); err == nil {
+		t.Error("attestation without attested credential data accepted")
+	}
+}
+
+func TestVerifyWebAuthnAssertion(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	coseKey := authenticator.coseKey(t)
+	clientData := clientDataJSON(t, "webauthn.get", "challenge-1", "https://"+testRPID)
+
+	authDataRaw, signature := authenticator.assert(t, testRPID, webauthnFlagUserPresent, clientData)
+	authData, err := ParseWebAuthnAuthData(authDataRaw)
+	if err != nil {
+		t.Fatalf("ParseWebAuthnAuthData: %v", err)
+	}
+	if err := CheckWebAuthnAuthData(authData, testRPID, false); err != nil {
+		t.Fatalf("CheckWebAuthnAuthData: %v", err)
+	}
+	if err := VerifyWebAuthnSignature(coseKey, authDataRaw, clientData, signature); err != nil {
+		t.Fatalf("VerifyWebAuthnSignature: %v", err)
+	}
+
+	// 篡改客户端数据、认证器数据或签名均应校验失败
+	tamperedClientData := clientDataJSON(t, "webauthn.get", "challenge-2", "https://"+testRPID)
+	if VerifyWebAuthnSignature(coseKey, authDataRaw, tamperedClientData, signature) == nil {
+		t.Error("signature accepted for different client data")
+	}
+	tamperedAuthData := append([]byte(nil), authDataRaw...)
+	tamperedAuthData[len(tamperedAuthData)-1]++
+	if VerifyWebAuthnSignature(coseKey, tamperedAuthData, clientData, signature) == nil {
+		t.Error("signature accepted for different authenticator data")
+	}
+	if VerifyWebAuthnSignature(newTestAuthenticator(t).coseKey(t), authDataRaw, clientData, signature) == nil {
+		t.Error("signature accepted for a different key")
+	}
+}
+
+func TestCheckWebAuthnAuthDataRejects(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	parse := func(rpID string, flags byte) *WebAuthnAuthData {
+		authData, err := ParseWebAuthnAuthData(authenticator.authData(t, rpID, flags, false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return authData
+	}
+
+	if CheckWebAuthnAuthData(parse("evil.example.com", webauthnFlagUserPresent), testRPID, false) == nil {
+		t.Error("wrong rpIdHash accepted")
+	}
+	if CheckWebAuthnAuthData(parse(testRPID, 0), testRPID, false) == nil {
+		t.Error("assertion without UP flag accepted")
+	}
+	if CheckWebAuthnAuthData(parse(testRPID, webauthnFlagUserVerified), testRPID, true) == nil {
+		t.Error("assertion with UV but without UP flag accepted")
+	}
+	if CheckWebAuthnAuthData(parse(testRPID, webauthnFlagUserPresent), testRPID, true) == nil {
+		t.Error("assertion without UV flag accepted when user verification is required")
+	}
+	if err := CheckWebAuthnAuthData(parse(testRPID, webauthnFlagUserPresent|webauthnFlagUserVerified), testRPID, true); err != nil {
+		t.Errorf("valid flags rejected: %v", err)
+	}
+}
+
+func TestCheckWebAuthnClientData(t *testing.T) {
+	challenge := base64.RawURLEncoding.EncodeToString([]byte("server-issued-challenge"))
+
+	parse := func(typ, challenge, origin string) *WebAuthnClientData {
+		clientData, err := ParseWebAuthnClientData(clientDataJSON(t, typ, challenge, origin), "webauthn.get")
+		if err != nil {
+			t.Fatalf("ParseWebAuthnClientData: %v", err)
+		}
+		return clientData
+	}
+
+	if err := CheckWebAuthnClientData(parse("webauthn.get", challenge, "https://"+testRPID), challenge, testRPID, nil); err != nil {
+		t.Errorf("valid client data rejected: %v", err)
+	}
+	if err := CheckWebAuthnClientData(parse("webauthn.get", challenge, "https://app."+testRPID), challenge, testRPID, nil); err != nil {
+		t.Errorf("subdomain origin rejected: %v", err)
+	}
+
+	if CheckWebAuthnClientData(parse("webauthn.get", "other-challenge", "https://"+testRPID), challenge, testRPID, nil) == nil {
+		t.Error("wrong challenge accepted")
+	}
+	if CheckWebAuthnClientData(parse("webauthn.get", challenge, "https://"+testRPID), "", testRPID, nil) == nil {
+		t.Error("empty expected challenge accepted")
+	}
+
+	for _, origin := range []string{
+		"https://evil.example.com",
+		"https://evil" + testRPID,
+		"http://" + testRPID,
+		"https://" + testRPID + ".evil.com",
+		"",
+	} {
+		if CheckWebAuthnClientData(parse("webauthn.get", challenge, origin), challenge, testRPID, nil) == nil {
+			t.Errorf("origin %q accepted", origin)
+		}
+	}
+
+	// 配置了允许的来源时只接受列表中的来源
+	origins := []string{"https://chat.example.com:8443"}
+	if CheckWebAuthnClientData(parse("webauthn.get", challenge, "https://"+testRPID), challenge, testRPID, origins) == nil {
+		t.Error("origin outside the configured list accepted")
+	}
+	if err := CheckWebAuthnClientData(parse("webauthn.get", challenge, origins[0]), challenge, testRPID, origins); err != nil {
+		t.Errorf("configured origin rejected: %v", err)
+	}
+
+	if WebAuthnOriginAllowed("http://localhost:8080", "localhost", nil) != true {
+		t.Error("http://localhost rejected")
+	}
+}
+
+func TestParseWebAuthnClientDataRejects(t *testing.T) {
+	if _, err := ParseWebAuthnClientData(clientDataJSON(t, "webauthn.create", "c", "https://"+testRPID), "webauthn.get"); err == nil {
+		t.Error("wrong client data type accepted")
+	}
+	crossOrigin := []byte(`{"type":"webauthn.get","challenge":"c","origin":"https://chat.example.com","crossOrigin":true}`)
+	if _, err := ParseWebAuthnClientData(crossOrigin, "webauthn.get"); err == nil {
+		t.Error("cross-origin client data accepted")
+	}
+	if _, err := ParseWebAuthnClientData([]byte("not json"), "webauthn.get"); err == nil {
+		t.Error("malformed client data accepted")
+	}
+}
+
+func TestCheckWebAuthnSignCount(t *testing.T) {
+	cases := []struct {
+		stored, received uint32
+		ok               bool
+	}{
+		{0, 0, true},  // 认证器不支持计数器
+		{0, 1, true},  // 首次使用
+		{5, 6, true},  // 正常增长
+		{5, 5, false}, // 未增长
+		{5, 3, false}, // 回退
+		{5, 0, false}, // 回退到 0
+	}
+	for _, tc := range cases {
+		err := CheckWebAuthnSignCount(tc.stored, tc.received)
+		if (err == nil) != tc.ok {
+			t.Errorf("CheckWebAuthnSignCount(%d, %d) error = %v, want ok=%v", tc.stored, tc.received, err, tc.ok)
+		}
+	}
+
+	// 认证器连续两次断言的计数器递增
+	authenticator := newTestAuthenticator(t)
+	clientData := clientDataJSON(t, "webauthn.get", "c", "https://"+testRPID)
+	first, _ := authenticator.assert(t, testRPID, webauthnFlagUserPresent, clientData)
+	second, _ := authenticator.assert(t, testRPID, webauthnFlagUserPresent, clientData)
+	firstData, _ := ParseWebAuthnAuthData(first)
+	secondData, _ := ParseWebAuthnAuthData(second)
+	if err := CheckWebAuthnSignCount(firstData.SignCount, secondData.SignCount); err != nil {
+		t.Errorf("increasing sign count rejected: %v", err)
+	}
+	if CheckWebAuthnSignCount(secondData.SignCount, firstData.SignCount) == nil {
+		t.Error("replayed older assertion accepted")
+	}
+}
+
+func TestParseWebAuthnAuthDataMalformed(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	valid := authenticator.authData(t, testRPID, webauthnFlagUserPresent, true)
+
+	for name, data := range map[string][]byte{
+		"too short":            valid[:36],
+		"truncated attested":   valid[:37+10],
+		"truncated public key": valid[:len(valid)-1],
+	} {
+		if _, err := ParseWebAuthnAuthData(data); err == nil {
+			t.Errorf("%s: malformed authenticator data accepted", name)
+		}
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	value, rest, err := DecodeCBOR(encodeCBOR(t, map[interface{}]interface{}{
+		"a": []byte{1, 2}, 1: -7, -1: "text",
+	}))
+	if err != nil {
+		t.Fatalf("DecodeCBOR: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("remaining bytes = %d, want 0", len(rest))
+	}
+	decoded := value.(map[interface{}]interface{})
+	if !bytes.Equal(decoded["a"].([]byte), []byte{1, 2}) || decoded[int64(1)] != int64(-7) || decoded[int64(-1)] != "text" {
+		t.Errorf("decoded = %#v", decoded)
+	}
+}
+
+func TestDecodeCBORMalformed(t *testing.T) {
+	deep := bytes.Repeat([]byte{0x81}, cborMaxDepth+2) // 多层嵌套的单元素数组
+	deep = append(deep, 0x00)
+
+	for name, data := range map[string][]byte{
+		"empty":                 {},
+		"truncated uint16":      {0x19, 0x01},
+		"truncated byte string": {0x45, 0x01, 0x02},
+		"huge byte string":      {0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"huge array":            {0x9a, 0xff, 0xff, 0xff, 0xff},
+		"truncated map value":   {0xa1, 0x01},
+		"indefinite length":     {0x5f, 0x41, 0x00, 0xff},
+		"reserved info":         {0x1c},
+		"integer overflow":      {0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"tag":                   {0xc0, 0x00},
+		"float":                 {0xf9, 0x3c, 0x00},
+		"array map key":         {0xa1, 0x80, 0x00},
+		"too deep":              deep,
+	} {
+		if _, _, err := DecodeCBOR(data); err == nil {
+			t.Errorf("%s: malformed CBOR accepted", name)
+		}
+	}
+
+	if _, err := ParseWebAuthnAttestation([]byte{0xa1, 0x63, 'f', 'm'}); err == nil {
+		t.Error("truncated attestation object accepted")
+	}
+	if _, err := ParseWebAuthnAttestation(encodeCBOR(t, "not a map")); err == nil {
+		t.Error("non-map attestation object accepted")
+	}
+	if _, _, err := ParseCOSEKey([]byte{0xa5, 0x01}); err == nil {
+		t.Error("truncated COSE key accepted")
+	}
+}